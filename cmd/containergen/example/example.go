@@ -0,0 +1,40 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package example declares types whose containers methods are generated by
+// containergen.
+package example
+
+import "time"
+
+//go:generate go run github.com/bcmills/go2go/cmd/containergen -type=UserIDs,Index,Name,Events,Notify,Set,Timeline,Durations
+
+type UserID int64
+
+type UserIDs []UserID
+
+type Doc struct {
+	Title string
+}
+
+type Index map[string]*Doc
+
+type Name string
+
+type Event struct {
+	At time.Time
+}
+
+type Events chan Event
+
+type Notify chan<- struct{}
+
+type Set[K comparable] map[K]struct{}
+
+// A Timeline declares its own Len, so containergen does not generate one.
+type Timeline []Event
+
+func (t Timeline) Len() int { return len(t) }
+
+type Durations []time.Duration
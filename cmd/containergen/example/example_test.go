@@ -0,0 +1,123 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package example_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bcmills/go2go/cmd/containergen/example"
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ interface {
+		containers.Lenner
+		containers.Capper
+		containers.IndexSetter[int, example.UserID]
+		containers.Ranger[int, example.UserID]
+	} = example.UserIDs(nil)
+
+	_ interface {
+		containers.Lenner
+		containers.IndexSetter[string, *example.Doc]
+		containers.Ranger[string, *example.Doc]
+	} = example.Index(nil)
+
+	_ interface {
+		containers.Lenner
+		containers.Indexer[int, byte]
+		containers.Ranger[int, rune]
+	} = example.Name("")
+
+	_ interface {
+		containers.Lenner
+		containers.Capper
+		containers.ElemRanger[example.Event]
+	} = example.Events(nil)
+
+	_ interface {
+		containers.Lenner
+		containers.Capper
+	} = example.Notify(nil)
+
+	_ containers.IndexSetter[string, struct{}]   = example.Set[string](nil)
+	_ containers.IndexSetter[int, example.Event] = example.Timeline(nil)
+)
+
+// collect records every call that r makes to its callback,
+// stopping after limit calls.
+func collect[K, V any](r containers.Ranger[K, V], limit int) (keys []K, elems []V, pairs [][2]any) {
+	n := 0
+	r.RangeKeys(func(k K) bool { keys = append(keys, k); n++; return n < limit })
+	n = 0
+	r.RangeElems(func(v V) bool { elems = append(elems, v); n++; return n < limit })
+	n = 0
+	r.Range(func(k K, v V) bool { pairs = append(pairs, [2]any{k, v}); n++; return n < limit })
+	return keys, elems, pairs
+}
+
+// TestMatchesContainers checks that the generated methods behave
+// like the ones on the corresponding containers types.
+func TestMatchesContainers(t *testing.T) {
+	ids := example.UserIDs{3, 1, 4, 1, 5}
+	s := containers.Slice[example.UserID](ids)
+	for _, limit := range []int{1, 3, 10} {
+		gk, ge, gp := collect[int, example.UserID](ids, limit)
+		wk, we, wp := collect[int, example.UserID](s, limit)
+		if !reflect.DeepEqual(gk, wk) || !reflect.DeepEqual(ge, we) || !reflect.DeepEqual(gp, wp) {
+			t.Errorf("UserIDs ranges differ from Slice with limit %d", limit)
+		}
+	}
+	for _, i := range []int{-1, 0, 4, 5} {
+		gx, gok := ids.Index(i)
+		wx, wok := s.Index(i)
+		if gx != wx || gok != wok {
+			t.Errorf("UserIDs.Index(%d) = %v, %v; Slice.Index = %v, %v", i, gx, gok, wx, wok)
+		}
+	}
+	ids.SetIndex(0, 9)
+	if ids.Len() != s.Len() || ids.Cap() != s.Cap() || s[0] != 9 {
+		t.Errorf("UserIDs.SetIndex or Len differs from Slice")
+	}
+
+	name := example.Name("héllo")
+	str := containers.String(name)
+	gk, ge, gp := collect[int, rune](name, 10)
+	wk, we, wp := collect[int, rune](str, 10)
+	if !reflect.DeepEqual(gk, wk) || !reflect.DeepEqual(ge, we) || !reflect.DeepEqual(gp, wp) {
+		t.Errorf("Name ranges differ from String")
+	}
+	for _, i := range []int{-1, 1, 2, 6} {
+		gb, gok := name.Index(i)
+		wb, wok := str.Index(i)
+		if gb != wb || gok != wok {
+			t.Errorf("Name.Index(%d) = %v, %v; String.Index = %v, %v", i, gb, gok, wb, wok)
+		}
+	}
+
+	ix := example.Index{}
+	doc := &example.Doc{Title: "Go"}
+	ix.SetIndex("go", doc)
+	if d, ok := ix.Index("go"); !ok || d != doc || ix.Len() != 1 {
+		t.Errorf("Index.Index(%q) = %v, %v after SetIndex", "go", d, ok)
+	}
+	if d, ok := ix.Index("rust"); ok || d != nil {
+		t.Errorf("Index.Index(%q) = %v, %v; want nil, false", "rust", d, ok)
+	}
+
+	ch := make(example.Events, 3)
+	ch <- example.Event{}
+	ch <- example.Event{}
+	close(ch)
+	if ch.Len() != 2 || ch.Cap() != 3 {
+		t.Errorf("Events Len, Cap = %d, %d; want 2, 3", ch.Len(), ch.Cap())
+	}
+	n := 0
+	ch.RangeElems(func(example.Event) bool { n++; return true })
+	if n != 2 {
+		t.Errorf("Events.RangeElems visited %d elements; want 2", n)
+	}
+}
@@ -0,0 +1,265 @@
+// Code generated by "containergen -type=UserIDs,Index,Name,Events,Notify,Set,Timeline,Durations"; DO NOT EDIT.
+
+package example
+
+import (
+	"time"
+)
+
+func (s UserIDs) Len() int {
+	return len(s)
+}
+
+func (s UserIDs) Cap() int {
+	return cap(s)
+}
+
+func (s UserIDs) SetIndex(i int, x UserID) {
+	s[i] = x
+}
+
+func (s UserIDs) Index(i int) (UserID, bool) {
+	if i < 0 || i >= len(s) {
+		return *new(UserID), false
+	}
+	return s[i], true
+}
+
+func (s UserIDs) RangeKeys(f func(i int) bool) {
+	for i := range s {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (s UserIDs) RangeElems(f func(x UserID) bool) {
+	for _, x := range s {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (s UserIDs) Range(f func(i int, x UserID) bool) {
+	for i, x := range s {
+		if !f(i, x) {
+			break
+		}
+	}
+}
+
+func (m Index) Len() int {
+	return len(m)
+}
+
+func (m Index) SetIndex(k string, v *Doc) {
+	m[k] = v
+}
+
+func (m Index) Index(k string) (*Doc, bool) {
+	v, ok := m[k]
+	return v, ok
+}
+
+func (m Index) RangeKeys(f func(string) bool) {
+	for k := range m {
+		if !f(k) {
+			break
+		}
+	}
+}
+
+func (m Index) RangeElems(f func(*Doc) bool) {
+	for _, v := range m {
+		if !f(v) {
+			break
+		}
+	}
+}
+
+func (m Index) Range(f func(string, *Doc) bool) {
+	for k, v := range m {
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+func (s Name) Len() int {
+	return len(s)
+}
+
+func (s Name) Index(i int) (byte, bool) {
+	if i < 0 || i >= len(s) {
+		return 0, false
+	}
+	return s[i], true
+}
+
+func (s Name) RangeKeys(f func(i int) bool) {
+	for i := range s {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (s Name) RangeElems(f func(r rune) bool) {
+	for _, r := range s {
+		if !f(r) {
+			break
+		}
+	}
+}
+
+func (s Name) Range(f func(i int, r rune) bool) {
+	for i, r := range s {
+		if !f(i, r) {
+			break
+		}
+	}
+}
+
+func (c Events) Len() int {
+	return len(c)
+}
+
+func (c Events) Cap() int {
+	return cap(c)
+}
+
+func (c Events) RangeElems(f func(Event) bool) {
+	for x := range c {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (c Notify) Len() int {
+	return len(c)
+}
+
+func (c Notify) Cap() int {
+	return cap(c)
+}
+
+func (m Set[K]) Len() int {
+	return len(m)
+}
+
+func (m Set[K]) SetIndex(k K, v struct{}) {
+	m[k] = v
+}
+
+func (m Set[K]) Index(k K) (struct{}, bool) {
+	v, ok := m[k]
+	return v, ok
+}
+
+func (m Set[K]) RangeKeys(f func(K) bool) {
+	for k := range m {
+		if !f(k) {
+			break
+		}
+	}
+}
+
+func (m Set[K]) RangeElems(f func(struct{}) bool) {
+	for _, v := range m {
+		if !f(v) {
+			break
+		}
+	}
+}
+
+func (m Set[K]) Range(f func(K, struct{}) bool) {
+	for k, v := range m {
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+func (s Timeline) Cap() int {
+	return cap(s)
+}
+
+func (s Timeline) SetIndex(i int, x Event) {
+	s[i] = x
+}
+
+func (s Timeline) Index(i int) (Event, bool) {
+	if i < 0 || i >= len(s) {
+		return *new(Event), false
+	}
+	return s[i], true
+}
+
+func (s Timeline) RangeKeys(f func(i int) bool) {
+	for i := range s {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (s Timeline) RangeElems(f func(x Event) bool) {
+	for _, x := range s {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (s Timeline) Range(f func(i int, x Event) bool) {
+	for i, x := range s {
+		if !f(i, x) {
+			break
+		}
+	}
+}
+
+func (s Durations) Len() int {
+	return len(s)
+}
+
+func (s Durations) Cap() int {
+	return cap(s)
+}
+
+func (s Durations) SetIndex(i int, x time.Duration) {
+	s[i] = x
+}
+
+func (s Durations) Index(i int) (time.Duration, bool) {
+	if i < 0 || i >= len(s) {
+		return *new(time.Duration), false
+	}
+	return s[i], true
+}
+
+func (s Durations) RangeKeys(f func(i int) bool) {
+	for i := range s {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (s Durations) RangeElems(f func(x time.Duration) bool) {
+	for _, x := range s {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (s Durations) Range(f func(i int, x time.Duration) bool) {
+	for i, x := range s {
+		if !f(i, x) {
+			break
+		}
+	}
+}
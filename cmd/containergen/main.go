@@ -0,0 +1,460 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Containergen adds the methods of the containers interfaces to named
+// slice, map, string, and channel types, so that they can be used as
+// containers without converting them to containers.Slice, containers.Map,
+// and so on.
+//
+// Usage:
+//
+//	containergen -type T[,T...] [-output file] [dir]
+//
+// Containergen type-checks the package in dir (by default the current
+// directory) and writes, to the output file (by default t_containers.go in
+// dir, where t is the lowercased name of the first type), these methods of
+// the corresponding containers type:
+//
+//   - for a string type: Len, Index, RangeKeys, RangeElems, and Range;
+//   - for a slice type: Len, Cap, Index, SetIndex, RangeKeys, RangeElems, and Range;
+//   - for a map type: Len, Index, SetIndex, RangeKeys, RangeElems, and Range;
+//   - for a channel type: Len, Cap, and, unless it is send-only, RangeElems.
+//
+// Each generated method behaves like the method of the same name on
+// containers.String, containers.Slice, containers.Map, or containers.Chan.
+// Their other methods, such as SizeBytes, Map's Delete, and Chan's Send, Recv,
+// and Close, are not generated, nor are methods that a type already declares.
+//
+// Containergen is intended to be invoked by go generate:
+//
+//	//go:generate go run github.com/bcmills/go2go/cmd/containergen -type=UserIDs,Index
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; required")
+	output    = flag.String("output", "", "output file name; default <dir>/<type>_containers.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: containergen -type T[,T...] [-output file] [dir]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("containergen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		usage()
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+
+	outName := *output
+	if outName == "" {
+		outName = filepath.Join(dir, strings.ToLower(names[0])+"_containers.go")
+	}
+
+	src, err := generate(dir, names, outName, strings.Join(os.Args[1:], " "))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outName, src, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the source of a file declaring the container methods of
+// the named types in the package in dir, ignoring the existing contents of the
+// output file. The header of the file records args as the arguments to
+// containergen.
+func generate(dir string, typeNames []string, outName, args string) ([]byte, error) {
+	pkg, err := load(dir, outName, typeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, imports: make(map[*types.Package]string)}
+	for _, name := range typeNames {
+		if err := g.generate(name); err != nil {
+			return nil, err
+		}
+	}
+	return g.format(args)
+}
+
+// A listedPackage holds the fields of interest from the output of go list.
+type listedPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	GoFiles    []string
+	Export     string
+	DepOnly    bool
+	Error      *struct{ Err string }
+}
+
+// load parses and type-checks the package in dir, omitting the file outName.
+// It reports type errors only if they occur in the declarations of the named
+// types.
+func load(dir, outName string, typeNames []string) (*types.Package, error) {
+	cmd := exec.Command("go", "list", "-e", "-export", "-deps",
+		"-json=ImportPath,Name,Dir,GoFiles,Export,DepOnly,Error", ".")
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v", err)
+	}
+
+	var target *listedPackage
+	exports := make(map[string]string)
+	for d := json.NewDecoder(bytes.NewReader(out)); ; {
+		p := new(listedPackage)
+		if err := d.Decode(p); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list: %v", err)
+		}
+		if p.DepOnly {
+			exports[p.ImportPath] = p.Export
+		} else {
+			target = p
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("no package in %s", dir)
+	}
+	if len(target.GoFiles) == 0 && target.Error != nil {
+		return nil, errors.New(target.Error.Err)
+	}
+
+	outAbs, err := filepath.Abs(outName)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range target.GoFiles {
+		path := filepath.Join(target.Dir, name)
+		if path == outAbs {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	var decls []ast.Node // declarations of the named types
+	for _, f := range files {
+		for _, d := range f.Decls {
+			if d, ok := d.(*ast.GenDecl); ok && d.Tok == token.TYPE {
+				for _, spec := range d.Specs {
+					if slices.Contains(typeNames, spec.(*ast.TypeSpec).Name.Name) {
+						decls = append(decls, spec)
+					}
+				}
+			}
+		}
+	}
+
+	lookup := func(path string) (io.ReadCloser, error) {
+		export, ok := exports[path]
+		if !ok || export == "" {
+			return nil, fmt.Errorf("no export data for %q", path)
+		}
+		return os.Open(export)
+	}
+	var declErr error
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "gc", lookup),
+		// Without the output file, the package may not type-check: for
+		// example, if it uses the generated methods to satisfy an interface.
+		// The declarations of the types themselves are all that matter.
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if !ok || declErr != nil {
+				return
+			}
+			for _, d := range decls {
+				if d.Pos() <= terr.Pos && terr.Pos < d.End() {
+					declErr = err
+				}
+			}
+		},
+	}
+	pkg, _ := conf.Check(target.ImportPath, fset, files, nil)
+	if declErr != nil {
+		return nil, declErr
+	}
+	return pkg, nil
+}
+
+// A generator accumulates the generated methods for a package.
+type generator struct {
+	pkg     *types.Package
+	imports map[*types.Package]string // package → local name
+	buf     bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// qualifier returns the name by which the generated file refers to pkg,
+// adding an import if needed.
+func (g *generator) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+	if name, ok := g.imports[pkg]; ok {
+		return name
+	}
+	name := pkg.Name()
+	for i := 2; g.nameInUse(name); i++ {
+		name = fmt.Sprintf("%s%d", pkg.Name(), i)
+	}
+	g.imports[pkg] = name
+	return name
+}
+
+func (g *generator) nameInUse(name string) bool {
+	if g.pkg.Scope().Lookup(name) != nil {
+		return true
+	}
+	for _, used := range g.imports {
+		if used == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// ident returns name, or a variant of it if name would shadow an identifier
+// that the generated methods refer to.
+func (g *generator) ident(name string, types ...string) string {
+	for i := 2; ; i++ {
+		clash := g.pkg.Scope().Lookup(name) != nil
+		for _, t := range types {
+			if mentions(t, name) {
+				clash = true
+			}
+		}
+		if !clash {
+			return name
+		}
+		name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), i)
+	}
+}
+
+// mentions reports whether the type expression t contains the identifier name.
+func mentions(t, name string) bool {
+	isIdent := func(r rune) bool {
+		return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r >= 0x80
+	}
+	for _, f := range strings.FieldsFunc(t, func(r rune) bool { return !isIdent(r) }) {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) generate(typeName string) error {
+	obj, ok := g.pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("%s is not a type in package %s", typeName, g.pkg.Name())
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok || obj.IsAlias() {
+		return fmt.Errorf("%s is not a defined type", typeName)
+	}
+
+	declared := make(map[string]bool)
+	for i := 0; i < named.NumMethods(); i++ {
+		declared[named.Method(i).Name()] = true
+	}
+	m := &methods{g: g, declared: declared}
+
+	// The receiver type: the type name, instantiated with its own
+	// type parameters if it is generic.
+	m.recvType = typeName
+	if tparams := named.TypeParams(); tparams.Len() > 0 {
+		var names []string
+		for i := 0; i < tparams.Len(); i++ {
+			names = append(names, tparams.At(i).Obj().Name())
+		}
+		m.recvType += "[" + strings.Join(names, ", ") + "]"
+	}
+
+	switch u := named.Underlying().(type) {
+	case *types.Basic:
+		if u.Info()&types.IsString == 0 {
+			break
+		}
+		m.stringMethods()
+		return nil
+	case *types.Slice:
+		m.sliceMethods(g.typeString(u.Elem()))
+		return nil
+	case *types.Map:
+		m.mapMethods(g.typeString(u.Key()), g.typeString(u.Elem()))
+		return nil
+	case *types.Chan:
+		m.chanMethods(g.typeString(u.Elem()), u.Dir() != types.SendOnly)
+		return nil
+	}
+	return fmt.Errorf("%s is not a string, slice, map, or channel type", typeName)
+}
+
+// methods emits the methods for a single type.
+type methods struct {
+	g        *generator
+	recvType string
+	declared map[string]bool
+}
+
+// method emits a method with the given name, unless the type already declares
+// one. The body is formatted with the receiver name as %[1]s.
+func (m *methods) method(name, recv, sig, body string) {
+	if m.declared[name] {
+		return
+	}
+	m.g.printf("\nfunc (%s %s) %s%s {\n", recv, m.recvType, name, sig)
+	m.g.printf(body, recv)
+	m.g.printf("}\n")
+}
+
+func (m *methods) idents(types []string, names ...string) []string {
+	var ids []string
+	for _, name := range names {
+		ids = append(ids, m.g.ident(name, append(types, m.recvType)...))
+	}
+	return ids
+}
+
+func (m *methods) stringMethods() {
+	id := m.idents(nil, "s", "f", "i", "r")
+	s, f, i, r := id[0], id[1], id[2], id[3]
+
+	m.method("Len", s, "() int", "\treturn len(%[1]s)\n")
+	m.method("Index", s, fmt.Sprintf("(%s int) (byte, bool)", i), fmt.Sprintf(
+		"\tif %[1]s < 0 || %[1]s >= len(%%[1]s) {\n\t\treturn 0, false\n\t}\n\treturn %%[1]s[%[1]s], true\n", i))
+	m.rangeMethods(s, f, fmt.Sprintf("%s int", i), fmt.Sprintf("%s rune", r), i, r)
+}
+
+func (m *methods) sliceMethods(elem string) {
+	id := m.idents([]string{elem}, "s", "f", "i", "x")
+	s, f, i, x := id[0], id[1], id[2], id[3]
+
+	m.method("Len", s, "() int", "\treturn len(%[1]s)\n")
+	m.method("Cap", s, "() int", "\treturn cap(%[1]s)\n")
+	m.method("SetIndex", s, fmt.Sprintf("(%s int, %s %s)", i, x, elem),
+		fmt.Sprintf("\t%%[1]s[%s] = %s\n", i, x))
+	m.method("Index", s, fmt.Sprintf("(%s int) (%s, bool)", i, elem), fmt.Sprintf(
+		"\tif %[1]s < 0 || %[1]s >= len(%%[1]s) {\n\t\treturn *new(%[2]s), false\n\t}\n\treturn %%[1]s[%[1]s], true\n", i, elem))
+	m.rangeMethods(s, f, fmt.Sprintf("%s int", i), fmt.Sprintf("%s %s", x, elem), i, x)
+}
+
+func (m *methods) mapMethods(key, elem string) {
+	id := m.idents([]string{key, elem}, "m", "f", "k", "v", "ok")
+	r, f, k, v, ok := id[0], id[1], id[2], id[3], id[4]
+
+	m.method("Len", r, "() int", "\treturn len(%[1]s)\n")
+	m.method("SetIndex", r, fmt.Sprintf("(%s %s, %s %s)", k, key, v, elem),
+		fmt.Sprintf("\t%%[1]s[%s] = %s\n", k, v))
+	m.method("Index", r, fmt.Sprintf("(%s %s) (%s, bool)", k, key, elem),
+		fmt.Sprintf("\t%[2]s, %[3]s := %%[1]s[%[1]s]\n\treturn %[2]s, %[3]s\n", k, v, ok))
+	m.rangeMethods(r, f, key, elem, k, v)
+}
+
+func (m *methods) chanMethods(elem string, recv bool) {
+	id := m.idents([]string{elem}, "c", "f", "x")
+	c, f, x := id[0], id[1], id[2]
+
+	m.method("Len", c, "() int", "\treturn len(%[1]s)\n")
+	m.method("Cap", c, "() int", "\treturn cap(%[1]s)\n")
+	if recv {
+		m.method("RangeElems", c, fmt.Sprintf("(%s func(%s) bool)", f, elem), fmt.Sprintf(
+			"\tfor %[2]s := range %%[1]s {\n\t\tif !%[1]s(%[2]s) {\n\t\t\tbreak\n\t\t}\n\t}\n", f, x))
+	}
+}
+
+// rangeMethods emits RangeKeys, RangeElems, and Range for a type that can be
+// ranged over with two iteration variables. The key and elem parameters are
+// the parameter lists of the callbacks, and k and v the names of the
+// iteration variables.
+func (m *methods) rangeMethods(recv, f, key, elem, k, v string) {
+	loop := func(vars, args string) string {
+		return fmt.Sprintf("\tfor %[2]s := range %%[1]s {\n\t\tif !%[1]s(%[3]s) {\n\t\t\tbreak\n\t\t}\n\t}\n", f, vars, args)
+	}
+	m.method("RangeKeys", recv, fmt.Sprintf("(%s func(%s) bool)", f, key), loop(k, k))
+	m.method("RangeElems", recv, fmt.Sprintf("(%s func(%s) bool)", f, elem), loop("_, "+v, v))
+	m.method("Range", recv, fmt.Sprintf("(%s func(%s, %s) bool)", f, key, elem), loop(k+", "+v, k+", "+v))
+}
+
+// format returns the formatted source of the generated file.
+func (g *generator) format(args string) ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by \"containergen %s\"; DO NOT EDIT.\n\n", args)
+	fmt.Fprintf(&out, "package %s\n", g.pkg.Name())
+	if len(g.imports) > 0 {
+		pkgs := make([]*types.Package, 0, len(g.imports))
+		for pkg := range g.imports {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Path() < pkgs[j].Path() })
+
+		fmt.Fprintf(&out, "\nimport (\n")
+		for _, pkg := range pkgs {
+			name := g.imports[pkg]
+			if name == pkg.Name() {
+				fmt.Fprintf(&out, "\t%q\n", pkg.Path())
+			} else {
+				fmt.Fprintf(&out, "\t%s %q\n", name, pkg.Path())
+			}
+		}
+		fmt.Fprintf(&out, ")\n")
+	}
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
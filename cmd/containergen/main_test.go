@@ -0,0 +1,92 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerated checks that the checked-in output for the example package is
+// up to date with both the generator and the go:generate directive.
+func TestGenerated(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("example", "example.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const directive = "//go:generate go run github.com/bcmills/go2go/cmd/containergen "
+	var args string
+	for _, line := range strings.Split(string(src), "\n") {
+		if strings.HasPrefix(line, directive) {
+			args = strings.TrimPrefix(line, directive)
+		}
+	}
+	typeList, ok := strings.CutPrefix(args, "-type=")
+	if !ok || strings.Contains(typeList, " ") {
+		t.Fatalf("unexpected go:generate arguments %q", args)
+	}
+
+	outName := filepath.Join("example", "userids_containers.go")
+	got, err := generate("example", strings.Split(typeList, ","), outName, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(outName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; run go generate in the example directory.\ngot:\n%s", outName, got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, tt := range []struct {
+		typ, want string
+	}{
+		{"Missing", "not a type"},
+		{"Doc", "not a string, slice, map, or channel type"},
+		{"UserID", "not a string, slice, map, or channel type"},
+	} {
+		_, err := generate("example", []string{tt.typ}, filepath.Join("example", "out.go"), "")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("generate(%s): got error %v; want %q", tt.typ, err, tt.want)
+		}
+	}
+}
+
+// TestRegenerate checks that a package that relies on its generated methods
+// can be regenerated, even though it does not type-check without them.
+func TestRegenerate(t *testing.T) {
+	outName := filepath.Join("testdata", "regen", "ids_containers.go")
+	got, err := generate(filepath.Join("testdata", "regen"), []string{"IDs"}, outName, "-type=IDs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(outName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("regenerated %s differs:\n%s", outName, got)
+	}
+}
+
+// TestTypeErrors checks that type errors in the declaration of a requested
+// type are reported, while those elsewhere in the package are tolerated.
+func TestTypeErrors(t *testing.T) {
+	dir := filepath.Join("testdata", "broken")
+	outName := filepath.Join(dir, "out.go")
+	if _, err := generate(dir, []string{"Good"}, outName, ""); err != nil {
+		t.Errorf("generate(Good): %v", err)
+	}
+	_, err := generate(dir, []string{"Good", "Bad"}, outName, "")
+	if want := "undefined: Undefined"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("generate(Good, Bad): got error %v; want %q", err, want)
+	}
+}
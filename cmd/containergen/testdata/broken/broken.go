@@ -0,0 +1,13 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package broken does not type-check, but only the declaration of Bad
+// is itself in error.
+package broken
+
+type Bad []Undefined
+
+type Good []int
+
+var _ = undefinedElsewhere
@@ -0,0 +1,13 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package regen uses its generated methods to satisfy a containers interface,
+// so it does not type-check without them.
+package regen
+
+import "github.com/bcmills/go2go/containers"
+
+type IDs []int
+
+var _ containers.ElemRanger[int] = IDs{}
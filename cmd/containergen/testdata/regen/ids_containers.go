@@ -0,0 +1,46 @@
+// Code generated by "containergen -type=IDs"; DO NOT EDIT.
+
+package regen
+
+func (s IDs) Len() int {
+	return len(s)
+}
+
+func (s IDs) Cap() int {
+	return cap(s)
+}
+
+func (s IDs) SetIndex(i int, x int) {
+	s[i] = x
+}
+
+func (s IDs) Index(i int) (int, bool) {
+	if i < 0 || i >= len(s) {
+		return *new(int), false
+	}
+	return s[i], true
+}
+
+func (s IDs) RangeKeys(f func(i int) bool) {
+	for i := range s {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (s IDs) RangeElems(f func(x int) bool) {
+	for _, x := range s {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (s IDs) Range(f func(i int, x int) bool) {
+	for i, x := range s {
+		if !f(i, x) {
+			break
+		}
+	}
+}
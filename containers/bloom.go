@@ -0,0 +1,129 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A BloomFilter is a probabilistic set of keys.
+// MayContain never reports false for a key that was added,
+// but may report true for a key that was not.
+type BloomFilter[K any] struct {
+	hash Hasher[K]
+	k    uint32
+	bits []uint64
+}
+
+// NewBloomFilter returns an empty BloomFilter with (at least) m bits,
+// setting k bits per key.
+func NewBloomFilter[K any](m, k int, hash Hasher[K]) *BloomFilter[K] {
+	if m <= 0 || k <= 0 {
+		panic(fmt.Sprintf("NewBloomFilter: invalid size (m=%d, k=%d)", m, k))
+	}
+	return &BloomFilter[K]{
+		hash: hash,
+		k:    uint32(k),
+		bits: make([]uint64, (m+63)/64),
+	}
+}
+
+// BloomFilterSize returns the number of bits m and hashes k that minimize
+// the size of a BloomFilter holding n keys with false-positive rate p.
+func BloomFilterSize(n int, p float64) (m, k int) {
+	if n <= 0 || p <= 0 || p >= 1 {
+		panic(fmt.Sprintf("BloomFilterSize: invalid parameters (n=%d, p=%v)", n, p))
+	}
+	m = int(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k = int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return m, k
+}
+
+func (f *BloomFilter[K]) nbits() uint64 { return uint64(len(f.bits)) * 64 }
+
+// SizeBytes reports the memory used by the filter and its bits,
+// not counting any variables captured by its Hasher.
+func (f *BloomFilter[K]) SizeBytes() int64 { return referencedSize(f) }
+
+// Add adds x to the filter.
+func (f *BloomFilter[K]) Add(x K) {
+	h1, h2 := probes(f.hash(x))
+	m := f.nbits()
+	for i := uint64(0); i < uint64(f.k); i++ {
+		b := (h1 + i*h2) % m
+		f.bits[b/64] |= 1 << (b % 64)
+	}
+}
+
+// MayContain reports whether x may have been added to the filter.
+func (f *BloomFilter[K]) MayContain(x K) bool {
+	h1, h2 := probes(f.hash(x))
+	m := f.nbits()
+	for i := uint64(0); i < uint64(f.k); i++ {
+		b := (h1 + i*h2) % m
+		if f.bits[b/64]&(1<<(b%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge adds all of the keys in other to f.
+// The filters must have the same size and Hasher.
+func (f *BloomFilter[K]) Merge(other *BloomFilter[K]) error {
+	if f.k != other.k || len(f.bits) != len(other.bits) {
+		return errors.New("BloomFilter.Merge: mismatched filter sizes")
+	}
+	for i, w := range other.bits {
+		f.bits[i] |= w
+	}
+	return nil
+}
+
+const bloomMagic = "bf\x01"
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The Hasher is not included in the encoding.
+func (f *BloomFilter[K]) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(bloomMagic)+4+8*len(f.bits))
+	b = append(b, bloomMagic...)
+	b = binary.LittleEndian.AppendUint32(b, f.k)
+	for _, w := range f.bits {
+		b = binary.LittleEndian.AppendUint64(b, w)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The receiver must already have a Hasher (for example, from NewBloomFilter),
+// and it must be the same Hasher that was used to build the encoded filter.
+func (f *BloomFilter[K]) UnmarshalBinary(data []byte) error {
+	if f.hash == nil {
+		return errors.New("BloomFilter.UnmarshalBinary: receiver has no Hasher")
+	}
+	if len(data) < len(bloomMagic)+4 || string(data[:len(bloomMagic)]) != bloomMagic {
+		return errors.New("BloomFilter.UnmarshalBinary: invalid encoding")
+	}
+	data = data[len(bloomMagic):]
+	k := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	if k == 0 || len(data) == 0 || len(data)%8 != 0 {
+		return errors.New("BloomFilter.UnmarshalBinary: invalid encoding")
+	}
+	bits := make([]uint64, len(data)/8)
+	for i := range bits {
+		bits[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	f.k = k
+	f.bits = bits
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/bcmills/go2go/unsafeslice"
+)
+
+// defaultChunkSize is the number of elements per chunk of a zero ChunkedSlice.
+const defaultChunkSize = 1024
+
+// A ChunkedSlice is a sequence stored in fixed-size chunks.
+//
+// Unlike a Slice, a ChunkedSlice never moves its elements: growing it
+// allocates a new chunk rather than copying the existing ones, so the pointer
+// returned by At remains valid (and refers to element i) until the element is
+// removed by Truncate.
+//
+// The zero ChunkedSlice is empty and ready to use, with chunks of 1024
+// elements.
+type ChunkedSlice[T any] struct {
+	size   int
+	chunks [][]T // each of length size
+	n      int
+}
+
+// NewChunkedSlice returns an empty ChunkedSlice with chunks of the given
+// number of elements.
+func NewChunkedSlice[T any](chunkSize int) *ChunkedSlice[T] {
+	if chunkSize <= 0 {
+		panic(fmt.Sprintf("NewChunkedSlice: chunk size %d is not positive", chunkSize))
+	}
+	return &ChunkedSlice[T]{size: chunkSize}
+}
+
+func (s *ChunkedSlice[T]) chunkSize() int {
+	if s.size == 0 {
+		s.size = defaultChunkSize
+	}
+	return s.size
+}
+
+func (s *ChunkedSlice[T]) Len() int         { return s.n }
+func (s *ChunkedSlice[T]) SizeBytes() int64 { return referencedSize(s) }
+
+// Cap returns the number of elements s can hold without allocating.
+func (s *ChunkedSlice[T]) Cap() int { return len(s.chunks) * s.size }
+
+// At returns a pointer to element i.
+// It panics if i is out of range.
+func (s *ChunkedSlice[T]) At(i int) *T {
+	if i < 0 || i >= s.n {
+		panic(fmt.Sprintf("index %d out of range [0:%d]", i, s.n))
+	}
+	return &s.chunks[i/s.size][i%s.size]
+}
+
+func (s *ChunkedSlice[T]) Index(i int) (T, bool) {
+	if i < 0 || i >= s.n {
+		return *new(T), false
+	}
+	return s.chunks[i/s.size][i%s.size], true
+}
+
+func (s *ChunkedSlice[T]) SetIndex(i int, x T) { *s.At(i) = x }
+
+// Append appends xs to s, allocating new chunks as needed.
+func (s *ChunkedSlice[T]) Append(xs ...T) {
+	size := s.chunkSize()
+	for len(xs) > 0 {
+		c := s.n / size
+		if c == len(s.chunks) {
+			s.chunks = append(s.chunks, make([]T, size))
+		}
+		m := copy(s.chunks[c][s.n%size:], xs)
+		s.n += m
+		xs = xs[m:]
+	}
+}
+
+// Truncate removes the elements at index n and above.
+// The chunks that held them are retained for reuse by Append;
+// call Compact to release them.
+func (s *ChunkedSlice[T]) Truncate(n int) {
+	if n < 0 || n > s.n {
+		panic(fmt.Sprintf("Truncate: length %d out of range [0:%d]", n, s.n))
+	}
+	for i := n; i < s.n; {
+		c, off := i/s.size, i%s.size
+		end := min(s.size, off+s.n-i)
+		clear(s.chunks[c][off:end])
+		i += end - off
+	}
+	s.n = n
+}
+
+// Compact releases the chunks that do not hold any elements.
+// It does not move the remaining elements.
+func (s *ChunkedSlice[T]) Compact() {
+	if s.size == 0 {
+		return
+	}
+	used := (s.n + s.size - 1) / s.size
+	clear(s.chunks[used:])
+	s.chunks = s.chunks[:used:used]
+	if used == 0 {
+		s.chunks = nil
+	}
+}
+
+// RangeChunks calls f with each chunk of s in order, truncated to the
+// elements it holds, stopping early if f returns false.
+// The chunks alias the storage of s.
+func (s *ChunkedSlice[T]) RangeChunks(f func(chunk Slice[T]) bool) {
+	for c := 0; c*s.size < s.n; c++ {
+		k := min(s.size, s.n-c*s.size)
+		if !f(s.chunks[c][:k:k]) {
+			break
+		}
+	}
+}
+
+// RangeChunkBytes is like RangeChunks, but views the memory of each chunk as
+// bytes, for bulk I/O. The byte views alias the storage of s.
+//
+// RangeChunkBytes panics if T contains pointers, since writing their bytes
+// would bypass the garbage collector. The layout of the bytes depends on the
+// platform's endianness and alignment.
+func (s *ChunkedSlice[T]) RangeChunkBytes(f func(b []byte) bool) {
+	if t := reflect.TypeOf((*T)(nil)).Elem(); typeHasPointers(t) {
+		panic(fmt.Sprintf("ChunkedSlice.RangeChunkBytes: element type %v contains pointers", t))
+	}
+	s.RangeChunks(func(chunk Slice[T]) bool {
+		return f(unsafeslice.Convert[T, byte](chunk))
+	})
+}
+
+func (s *ChunkedSlice[T]) RangeKeys(f func(i int) bool) {
+	for i := 0; i < s.n; i++ {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (s *ChunkedSlice[T]) RangeElems(f func(x T) bool) {
+	s.Range(func(_ int, x T) bool { return f(x) })
+}
+
+func (s *ChunkedSlice[T]) Range(f func(i int, x T) bool) {
+	i := 0
+	s.RangeChunks(func(chunk Slice[T]) bool {
+		for _, x := range chunk {
+			if !f(i, x) {
+				return false
+			}
+			i++
+		}
+		return true
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Lenner                = (*containers.ChunkedSlice[int])(nil)
+	_ containers.Capper                = (*containers.ChunkedSlice[int])(nil)
+	_ containers.IndexSetter[int, int] = (*containers.ChunkedSlice[int])(nil)
+	_ containers.Ranger[int, int]      = (*containers.ChunkedSlice[int])(nil)
+)
+
+func TestChunkedSliceStableAddresses(t *testing.T) {
+	s := containers.NewChunkedSlice[int](4)
+	var ptrs []*int
+	for i := 0; i < 50; i++ {
+		s.Append(i)
+		ptrs = append(ptrs, s.At(i))
+	}
+	s.Append(make([]int, 100)...)
+
+	// Growing s never moves its existing elements.
+	for i, p := range ptrs {
+		if p != s.At(i) {
+			t.Fatalf("address of element %d changed as the ChunkedSlice grew", i)
+		}
+		if *p != i {
+			t.Fatalf("*At(%d) = %d; want %d", i, *p, i)
+		}
+	}
+
+	// Writes through the pointers are visible through Index, and vice versa.
+	*ptrs[7] = 700
+	s.SetIndex(8, 800)
+	if x, _ := s.Index(7); x != 700 {
+		t.Errorf("Index(7) = %d; want 700", x)
+	}
+	if *ptrs[8] != 800 {
+		t.Errorf("*At(8) = %d; want 800", *ptrs[8])
+	}
+
+	// Truncating and compacting leaves the remaining elements in place.
+	s.Truncate(10)
+	s.Compact()
+	for i, p := range ptrs[:10] {
+		if p != s.At(i) {
+			t.Fatalf("address of element %d changed after Truncate and Compact", i)
+		}
+	}
+	if s.Len() != 10 || s.Cap() != 12 {
+		t.Errorf("Len(), Cap() after Compact = %d, %d; want 10, 12", s.Len(), s.Cap())
+	}
+}
+
+func TestChunkedSlice(t *testing.T) {
+	var s containers.ChunkedSlice[uint16]
+	if s.Len() != 0 || s.Cap() != 0 {
+		t.Errorf("zero ChunkedSlice: Len(), Cap() = %d, %d; want 0, 0", s.Len(), s.Cap())
+	}
+	want := make([]uint16, 2500)
+	for i := range want {
+		want[i] = uint16(i)
+	}
+	s.Append(want...)
+	if s.Len() != 2500 || s.Cap() != 3*1024 {
+		t.Errorf("Len(), Cap() = %d, %d; want 2500, %d", s.Len(), s.Cap(), 3*1024)
+	}
+
+	var chunkLens []int
+	var got []uint16
+	s.RangeChunks(func(chunk containers.Slice[uint16]) bool {
+		chunkLens = append(chunkLens, len(chunk))
+		got = append(got, chunk...)
+		return true
+	})
+	if want := []int{1024, 1024, 452}; !slices.Equal(chunkLens, want) {
+		t.Errorf("RangeChunks chunk lengths = %v; want %v", chunkLens, want)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("RangeChunks contents differ from appended values")
+	}
+
+	nbytes := 0
+	s.RangeChunkBytes(func(b []byte) bool {
+		nbytes += len(b)
+		return true
+	})
+	if nbytes != 2*2500 {
+		t.Errorf("RangeChunkBytes visited %d bytes; want %d", nbytes, 2*2500)
+	}
+
+	var ps containers.ChunkedSlice[*int]
+	ps.Append(new(int))
+	mustPanic(t, "RangeChunkBytes with pointer elements", func() {
+		ps.RangeChunkBytes(func([]byte) bool { return true })
+	})
+
+	// Truncate zeroes the removed elements, and Append reuses their chunks.
+	p := s.At(1500)
+	s.Truncate(1000)
+	if *p != 0 {
+		t.Errorf("element 1500 after Truncate(1000) = %d; want 0", *p)
+	}
+	if s.Cap() != 3*1024 {
+		t.Errorf("Cap() after Truncate = %d; want %d", s.Cap(), 3*1024)
+	}
+	s.Append(1, 2)
+	got = got[:0]
+	s.RangeElems(func(x uint16) bool {
+		got = append(got, x)
+		return true
+	})
+	if want := append(want[:1000:1000], 1, 2); !slices.Equal(got, want) {
+		t.Errorf("contents after Truncate and Append differ")
+	}
+
+	s.Truncate(0)
+	s.Compact()
+	if s.Len() != 0 || s.Cap() != 0 {
+		t.Errorf("Len(), Cap() after Truncate(0) and Compact = %d, %d; want 0, 0", s.Len(), s.Cap())
+	}
+	if _, ok := s.Index(0); ok {
+		t.Errorf("Index(0) of empty ChunkedSlice reported ok")
+	}
+}
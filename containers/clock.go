@@ -0,0 +1,163 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// A Clock tells the time, and signals when a duration has elapsed.
+// Containers that depend on time accept a Clock so that tests can
+// substitute a ManualClock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+
+	// AfterFunc arranges to call f once d has elapsed,
+	// and returns a Timer that can cancel or reschedule the call.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// A Timer is a pending call scheduled by Clock.AfterFunc.
+// Its methods behave like those of time.Timer: Stop and Reset report whether
+// the call was still pending.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SystemClock is the Clock implemented by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// A ManualClock is a Clock that only changes when it is advanced explicitly.
+// It is safe for concurrent use.
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*clockWaiter
+}
+
+// A clockWaiter is a pending After channel or AfterFunc call.
+type clockWaiter struct {
+	when time.Time
+	c    chan time.Time // for After
+	f    func()         // for AfterFunc
+}
+
+// NewManualClock returns a ManualClock set to now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the clock's time once the clock
+// has been advanced by at least d.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, &clockWaiter{when: c.now.Add(d), c: ch})
+	return ch
+}
+
+// AfterFunc arranges to call f once the clock has been advanced by at least d.
+// f is called by the goroutine that calls Advance, after Advance has released
+// the clock's lock, or by AfterFunc or Reset itself if d <= 0.
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &manualTimer{c: c, w: &clockWaiter{f: f}}
+	t.Reset(d)
+	return t
+}
+
+type manualTimer struct {
+	c *ManualClock
+	w *clockWaiter
+}
+
+func (t *manualTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	return t.c.remove(t.w)
+}
+
+func (t *manualTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	pending := t.c.remove(t.w)
+	if d <= 0 {
+		t.c.mu.Unlock()
+		t.w.f()
+		return pending
+	}
+	t.w.when = t.c.now.Add(d)
+	t.c.waiters = append(t.c.waiters, t.w)
+	t.c.mu.Unlock()
+	return pending
+}
+
+// remove removes w from the pending waiters, reporting whether it was pending.
+// c.mu must be held.
+func (c *ManualClock) remove(w *clockWaiter) bool {
+	for i, x := range c.waiters {
+		if x == w {
+			c.waiters = slices.Delete(c.waiters, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward by d,
+// firing the channels and calling the functions of any After and AfterFunc
+// calls that have come due.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due []func()
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		switch {
+		case w.when.After(c.now):
+			pending = append(pending, w)
+		case w.f != nil:
+			due = append(due, w.f)
+		default:
+			w.c <- c.now
+		}
+	}
+	clear(c.waiters[len(pending):])
+	c.waiters = pending
+	c.mu.Unlock()
+
+	for _, f := range due {
+		f()
+	}
+}
+
+// Waiters returns the number of After channels that have not yet fired,
+// plus the number of AfterFunc calls that are still pending.
+// Tests can use it to wait until a goroutine is blocked on the clock.
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
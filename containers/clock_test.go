@@ -0,0 +1,52 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+func TestManualClockAfterFunc(t *testing.T) {
+	clock := containers.NewManualClock(time.Unix(0, 0))
+	calls := 0
+	timer := clock.AfterFunc(time.Second, func() { calls++ })
+
+	clock.Advance(time.Second - 1)
+	if calls != 0 {
+		t.Fatalf("AfterFunc called %d times early", calls)
+	}
+	if !timer.Reset(2 * time.Second) {
+		t.Errorf("Reset of a pending timer = false; want true")
+	}
+	if n := clock.Waiters(); n != 1 {
+		t.Errorf("Waiters() = %d after Reset; want 1", n)
+	}
+	clock.Advance(time.Second)
+	if calls != 0 {
+		t.Fatalf("AfterFunc called at its original time after Reset")
+	}
+	clock.Advance(time.Second)
+	if calls != 1 {
+		t.Fatalf("AfterFunc called %d times; want 1", calls)
+	}
+	if timer.Stop() {
+		t.Errorf("Stop of a fired timer = true; want false")
+	}
+
+	timer.Reset(time.Second)
+	if !timer.Stop() {
+		t.Errorf("Stop of a pending timer = false; want true")
+	}
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Waiters() = %d after Stop; want 0", n)
+	}
+	clock.Advance(time.Hour)
+	if calls != 1 {
+		t.Errorf("AfterFunc called %d times after Stop; want 1", calls)
+	}
+}
@@ -13,6 +13,10 @@ type Capper interface {
 	Cap() int
 }
 
+type Sizer interface {
+	SizeBytes() int64
+}
+
 type Indexer[K, V any] interface {
 	Index(K) (V, bool)
 }
@@ -22,6 +26,10 @@ type IndexSetter[K, V any] interface {
 	SetIndex(K, V)
 }
 
+type Deleter[K any] interface {
+	Delete(K)
+}
+
 type Sender[V any] interface {
 	Send(V)
 }
@@ -51,6 +59,7 @@ type Ranger[K, V any] interface {
 type String string
 
 func (s String) Len() int { return len(s) }
+func (s String) SizeBytes() int64 { return int64(len(s)) }
 
 func (s String) Index(i int) (byte, bool) {
 	if i < 0 || i >= len(s) {
@@ -87,6 +96,7 @@ type Slice[T any] []T
 
 func (s Slice[T]) Len() int { return len(s) }
 func (s Slice[T]) Cap() int { return cap(s) }
+func (s Slice[T]) SizeBytes() int64 { return referencedSize(s) }
 func (s Slice[T]) SetIndex(i int, x T) { s[i] = x }
 
 func (s Slice[T]) Index(i int) (T, bool) {
@@ -123,7 +133,9 @@ func (s Slice[T]) Range(f func(i int, x T) bool) {
 type Map[K comparable, V any] map[K]V
 
 func (m Map[K, V]) Len() int { return len(m) }
+func (m Map[K, V]) SizeBytes() int64 { return referencedSize(m) }
 func (m Map[K, V]) SetIndex(k K, v V) { m[k] = v }
+func (m Map[K, V]) Delete(k K) { delete(m, k) }
 
 func (m Map[K, V]) Index(k K) (V, bool) {
 	v, ok := m[k]
@@ -160,6 +172,7 @@ type Chan[T any] chan T
 
 func (c Chan[T]) Len() int { return len(c) }
 func (c Chan[T]) Cap() int { return cap(c) }
+func (c Chan[T]) SizeBytes() int64 { return referencedSize(c) }
 func (c Chan[T]) Send(x T) { c <- x }
 func (c Chan[T]) Close() { close(c) }
 
@@ -180,6 +193,7 @@ type RecvChan[T any] <-chan T
 
 func (c RecvChan[T]) Len() int { return len(c) }
 func (c RecvChan[T]) Cap() int { return cap(c) }
+func (c RecvChan[T]) SizeBytes() int64 { return referencedSize(c) }
 
 func (c RecvChan[T]) Recv() (T, bool) {
 	x, ok := <-c
@@ -198,5 +212,6 @@ type SendChan[T any] chan<- T
 
 func (c SendChan[T]) Len() int { return len(c) }
 func (c SendChan[T]) Cap() int { return cap(c) }
+func (c SendChan[T]) SizeBytes() int64 { return referencedSize(c) }
 func (c SendChan[T]) Send(x T) { c <- x }
 func (c SendChan[T]) Close() { close(c) }
@@ -0,0 +1,121 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A CountMinSketch estimates the number of times each key has been added.
+// Estimate never undercounts, and overcounts by at most a fraction of the
+// total count that shrinks with the width of the sketch.
+type CountMinSketch[K any] struct {
+	hash   Hasher[K]
+	width  uint32
+	depth  uint32
+	counts []uint64 // depth rows of width counters
+}
+
+// NewCountMinSketch returns an empty CountMinSketch with depth rows of
+// width counters each.
+func NewCountMinSketch[K any](width, depth int, hash Hasher[K]) *CountMinSketch[K] {
+	if width <= 0 || depth <= 0 || uint64(width) > 1<<32-1 || uint64(depth) > 1<<32-1 {
+		panic(fmt.Sprintf("NewCountMinSketch: invalid size (width=%d, depth=%d)", width, depth))
+	}
+	if width > math.MaxInt/depth {
+		panic(fmt.Sprintf("NewCountMinSketch: %dx%d counters overflow int", width, depth))
+	}
+	return &CountMinSketch[K]{
+		hash:   hash,
+		width:  uint32(width),
+		depth:  uint32(depth),
+		counts: make([]uint64, width*depth),
+	}
+}
+
+// SizeBytes reports the memory used by the sketch and its counters,
+// not counting any variables captured by its Hasher.
+func (s *CountMinSketch[K]) SizeBytes() int64 { return referencedSize(s) }
+
+// Add increments the count for x by n.
+func (s *CountMinSketch[K]) Add(x K, n uint64) {
+	h1, h2 := probes(s.hash(x))
+	w := uint64(s.width)
+	for i := uint64(0); i < uint64(s.depth); i++ {
+		s.counts[i*w+(h1+i*h2)%w] += n
+	}
+}
+
+// Estimate returns an upper bound on the count for x.
+func (s *CountMinSketch[K]) Estimate(x K) uint64 {
+	h1, h2 := probes(s.hash(x))
+	w := uint64(s.width)
+	var est uint64
+	for i := uint64(0); i < uint64(s.depth); i++ {
+		c := s.counts[i*w+(h1+i*h2)%w]
+		if i == 0 || c < est {
+			est = c
+		}
+	}
+	return est
+}
+
+// Merge adds all of the counts in other to s.
+// The sketches must have the same dimensions and Hasher.
+func (s *CountMinSketch[K]) Merge(other *CountMinSketch[K]) error {
+	if s.width != other.width || s.depth != other.depth {
+		return errors.New("CountMinSketch.Merge: mismatched sketch dimensions")
+	}
+	for i, c := range other.counts {
+		s.counts[i] += c
+	}
+	return nil
+}
+
+const countMinMagic = "cm\x01"
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The Hasher is not included in the encoding.
+func (s *CountMinSketch[K]) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(countMinMagic)+8+8*len(s.counts))
+	b = append(b, countMinMagic...)
+	b = binary.LittleEndian.AppendUint32(b, s.width)
+	b = binary.LittleEndian.AppendUint32(b, s.depth)
+	for _, c := range s.counts {
+		b = binary.LittleEndian.AppendUint64(b, c)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The receiver must already have a Hasher (for example, from
+// NewCountMinSketch), and it must be the same Hasher that was used to build
+// the encoded sketch.
+func (s *CountMinSketch[K]) UnmarshalBinary(data []byte) error {
+	if s.hash == nil {
+		return errors.New("CountMinSketch.UnmarshalBinary: receiver has no Hasher")
+	}
+	if len(data) < len(countMinMagic)+8 || string(data[:len(countMinMagic)]) != countMinMagic {
+		return errors.New("CountMinSketch.UnmarshalBinary: invalid encoding")
+	}
+	data = data[len(countMinMagic):]
+	width := binary.LittleEndian.Uint32(data)
+	depth := binary.LittleEndian.Uint32(data[4:])
+	data = data[8:]
+	// Compare by division, since width*depth*8 may overflow uint64.
+	if n := uint64(len(data)); width == 0 || depth == 0 || n%8 != 0 || n/8%uint64(width) != 0 || n/8/uint64(width) != uint64(depth) {
+		return errors.New("CountMinSketch.UnmarshalBinary: invalid encoding")
+	}
+	counts := make([]uint64, len(data)/8)
+	for i := range counts {
+		counts[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	s.width, s.depth, s.counts = width, depth, counts
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// A COWSlice is a copy-on-write Slice for read-mostly concurrent use.
+//
+// Readers load an immutable snapshot without locking. Writers are serialized,
+// and each write clones the current snapshot, modifies the clone, and
+// publishes it atomically. The zero COWSlice is empty and ready to use.
+type COWSlice[T any] struct {
+	mu sync.Mutex // serializes writers
+	p  atomic.Pointer[Slice[T]]
+}
+
+// Load returns the current snapshot of s.
+// The caller must not modify the returned Slice.
+func (s *COWSlice[T]) Load() Slice[T] {
+	if p := s.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Update calls f with a private copy of the current contents of s,
+// and atomically replaces the contents of s with the Slice that f returns.
+func (s *COWSlice[T]) Update(f func(Slice[T]) Slice[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := f(slices.Clone(s.Load()))
+	s.p.Store(&next)
+}
+
+func (s *COWSlice[T]) Len() int { return s.Load().Len() }
+
+func (s *COWSlice[T]) SizeBytes() int64 {
+	n := int64(unsafe.Sizeof(*s))
+	if p := s.p.Load(); p != nil {
+		n += int64(unsafe.Sizeof(*p)) + p.SizeBytes()
+	}
+	return n
+}
+
+func (s *COWSlice[T]) Index(i int) (T, bool) { return s.Load().Index(i) }
+
+func (s *COWSlice[T]) SetIndex(i int, x T) {
+	s.Update(func(c Slice[T]) Slice[T] {
+		c[i] = x
+		return c
+	})
+}
+
+// Append atomically appends xs to s.
+func (s *COWSlice[T]) Append(xs ...T) {
+	s.Update(func(c Slice[T]) Slice[T] {
+		return append(c, xs...)
+	})
+}
+
+// RangeKeys, RangeElems, and Range iterate over a single snapshot of s,
+// and are unaffected by concurrent writes.
+
+func (s *COWSlice[T]) RangeKeys(f func(i int) bool)  { s.Load().RangeKeys(f) }
+func (s *COWSlice[T]) RangeElems(f func(x T) bool)   { s.Load().RangeElems(f) }
+func (s *COWSlice[T]) Range(f func(i int, x T) bool) { s.Load().Range(f) }
+
+// A COWMap is a copy-on-write Map for read-mostly concurrent use.
+//
+// Readers load an immutable snapshot without locking. Writers are serialized,
+// and each write clones the current snapshot, modifies the clone, and
+// publishes it atomically. The zero COWMap is empty and ready to use.
+type COWMap[K comparable, V any] struct {
+	mu sync.Mutex // serializes writers
+	p  atomic.Pointer[Map[K, V]]
+}
+
+// Load returns the current snapshot of m.
+// The caller must not modify the returned Map.
+func (m *COWMap[K, V]) Load() Map[K, V] {
+	if p := m.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Update calls f with a private copy of the current contents of m,
+// and atomically publishes the result once f returns.
+// Concurrent readers observe either all or none of the changes made by f.
+func (m *COWMap[K, V]) Update(f func(m Map[K, V])) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	next := Map[K, V](maps.Clone(m.Load()))
+	if next == nil {
+		next = make(Map[K, V])
+	}
+	f(next)
+	m.p.Store(&next)
+}
+
+func (m *COWMap[K, V]) Len() int { return m.Load().Len() }
+
+func (m *COWMap[K, V]) SizeBytes() int64 {
+	n := int64(unsafe.Sizeof(*m))
+	if p := m.p.Load(); p != nil {
+		n += int64(unsafe.Sizeof(*p)) + p.SizeBytes()
+	}
+	return n
+}
+
+func (m *COWMap[K, V]) Index(k K) (V, bool) { return m.Load().Index(k) }
+
+func (m *COWMap[K, V]) SetIndex(k K, v V) {
+	m.Update(func(c Map[K, V]) { c[k] = v })
+}
+
+func (m *COWMap[K, V]) Delete(k K) {
+	m.Update(func(c Map[K, V]) { delete(c, k) })
+}
+
+// RangeKeys, RangeElems, and Range iterate over a single snapshot of m,
+// and are unaffected by concurrent writes.
+
+func (m *COWMap[K, V]) RangeKeys(f func(K) bool)  { m.Load().RangeKeys(f) }
+func (m *COWMap[K, V]) RangeElems(f func(V) bool) { m.Load().RangeElems(f) }
+func (m *COWMap[K, V]) Range(f func(K, V) bool)   { m.Load().Range(f) }
@@ -0,0 +1,161 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.IndexSetter[int, int]    = (*containers.COWSlice[int])(nil)
+	_ containers.Ranger[int, int]         = (*containers.COWSlice[int])(nil)
+	_ containers.IndexSetter[string, int] = (*containers.COWMap[string, int])(nil)
+	_ containers.Ranger[string, int]      = (*containers.COWMap[string, int])(nil)
+)
+
+func TestCOWSlice(t *testing.T) {
+	var s containers.COWSlice[int]
+	if s.Len() != 0 || s.Load() != nil {
+		t.Errorf("zero COWSlice: Len, Load = %d, %v; want 0, nil", s.Len(), s.Load())
+	}
+	s.Append(1, 2, 3)
+	snap := s.Load()
+
+	// Writes after a Load do not affect the loaded snapshot.
+	s.SetIndex(0, 10)
+	s.Append(4)
+	if want := []int{1, 2, 3}; !slices.Equal(snap, want) {
+		t.Errorf("snapshot after writes = %v; want %v", snap, want)
+	}
+	if got, want := s.Load(), []int{10, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("Load() = %v; want %v", got, want)
+	}
+	if x, ok := s.Index(3); !ok || x != 4 {
+		t.Errorf("Index(3) = %d, %v; want 4, true", x, ok)
+	}
+
+	// Range iterates over the snapshot taken when it starts.
+	var got []int
+	s.RangeElems(func(x int) bool {
+		if len(got) == 0 {
+			s.Append(5)
+			s.SetIndex(1, 20)
+		}
+		got = append(got, x)
+		return true
+	})
+	if want := []int{10, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("RangeElems during writes visited %v; want %v", got, want)
+	}
+
+	// Update sees a private copy, even if it modifies it in place.
+	before := s.Load()
+	s.Update(func(c containers.Slice[int]) containers.Slice[int] {
+		c[0] = -1
+		return c[:2]
+	})
+	if want := []int{10, 20, 3, 4, 5}; !slices.Equal(before, want) {
+		t.Errorf("snapshot after Update = %v; want %v", before, want)
+	}
+	if got, want := s.Load(), []int{-1, 20}; !slices.Equal(got, want) {
+		t.Errorf("Load() after Update = %v; want %v", got, want)
+	}
+}
+
+func TestCOWMap(t *testing.T) {
+	var m containers.COWMap[string, int]
+	if m.Len() != 0 {
+		t.Errorf("zero COWMap: Len() = %d; want 0", m.Len())
+	}
+	m.SetIndex("a", 1)
+	m.SetIndex("b", 2)
+	snap := m.Load()
+
+	m.SetIndex("a", 10)
+	m.Delete("b")
+	m.SetIndex("c", 3)
+	if want := map[string]int{"a": 1, "b": 2}; !maps.Equal(snap, want) {
+		t.Errorf("snapshot after writes = %v; want %v", snap, want)
+	}
+	if got, want := m.Load(), map[string]int{"a": 10, "c": 3}; !maps.Equal(got, want) {
+		t.Errorf("Load() = %v; want %v", got, want)
+	}
+
+	// An Update is published as a whole.
+	before := m.Load()
+	m.Update(func(c containers.Map[string, int]) {
+		c["d"] = 4
+		delete(c, "a")
+	})
+	if want := map[string]int{"a": 10, "c": 3}; !maps.Equal(before, want) {
+		t.Errorf("snapshot after Update = %v; want %v", before, want)
+	}
+	if got, want := m.Load(), map[string]int{"c": 3, "d": 4}; !maps.Equal(got, want) {
+		t.Errorf("Load() after Update = %v; want %v", got, want)
+	}
+}
+
+func TestCOWConcurrent(t *testing.T) {
+	// Each Update keeps every element of the slice equal to its length,
+	// and every value of the map equal to its size,
+	// so readers can detect a partially-applied write.
+	var s containers.COWSlice[int]
+	var m containers.COWMap[int, int]
+	const writes = 200
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= writes; i++ {
+			s.Update(func(c containers.Slice[int]) containers.Slice[int] {
+				c = append(c, 0)
+				for j := range c {
+					c[j] = len(c)
+				}
+				return c
+			})
+			m.Update(func(c containers.Map[int, int]) {
+				c[i] = 0
+				for k := range c {
+					c[k] = len(c)
+				}
+			})
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				snap := s.Load()
+				for _, x := range snap {
+					if x != len(snap) {
+						t.Errorf("COWSlice snapshot of length %d contains %d", len(snap), x)
+						return
+					}
+				}
+				msnap := m.Load()
+				for _, v := range msnap {
+					if v != len(msnap) {
+						t.Errorf("COWMap snapshot of size %d contains %d", len(msnap), v)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s.Len() != writes || m.Len() != writes {
+		t.Errorf("after %d writes, Len() = %d, %d", writes, s.Len(), m.Len())
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import "unicode/utf8"
+
+// A Cursor is a position in an ordered container that can move in either
+// direction, one entry at a time.
+//
+// Besides the entries themselves, a Cursor has two invalid positions:
+// before the first entry, where a new Cursor starts, and after the last.
+// Next from before the first entry moves to the first, and Prev from after
+// the last entry moves to the last.
+type Cursor[K, V any] interface {
+	// Seek moves the cursor to the first entry whose key is at or after k,
+	// and reports whether such an entry exists.
+	Seek(k K) bool
+
+	// Next moves the cursor to the following entry and reports whether it exists.
+	Next() bool
+
+	// Prev moves the cursor to the preceding entry and reports whether it exists.
+	Prev() bool
+
+	// Valid reports whether the cursor is positioned at an entry.
+	Valid() bool
+
+	// Key and Value return the key and value of the current entry,
+	// or zero values if the cursor is not Valid.
+	Key() K
+	Value() V
+}
+
+// A SliceCursor is a Cursor over the elements of a Slice, keyed by index.
+type SliceCursor[T any] struct {
+	s Slice[T]
+	i int // -1 before the first element, len(s) after the last
+}
+
+// Cursor returns a new Cursor positioned before the first element of s.
+func (s Slice[T]) Cursor() *SliceCursor[T] { return &SliceCursor[T]{s: s, i: -1} }
+
+func (c *SliceCursor[T]) Valid() bool { return c.i >= 0 && c.i < len(c.s) }
+
+func (c *SliceCursor[T]) Seek(i int) bool {
+	c.i = min(max(i, 0), len(c.s))
+	return c.Valid()
+}
+
+func (c *SliceCursor[T]) Next() bool {
+	if c.i < len(c.s) {
+		c.i++
+	}
+	return c.Valid()
+}
+
+func (c *SliceCursor[T]) Prev() bool {
+	if c.i >= 0 {
+		c.i--
+	}
+	return c.Valid()
+}
+
+func (c *SliceCursor[T]) Key() int {
+	if !c.Valid() {
+		return 0
+	}
+	return c.i
+}
+
+func (c *SliceCursor[T]) Value() T {
+	if !c.Valid() {
+		return *new(T)
+	}
+	return c.s[c.i]
+}
+
+// A StringCursor is a Cursor over the runes of a String,
+// keyed by byte offset, consistent with String.Range.
+type StringCursor struct {
+	s String
+	i int // byte offset of the current rune; -1 before the first, len(s) after the last
+	r rune
+	n int // width of the current rune
+}
+
+// Cursor returns a new Cursor positioned before the first rune of s.
+func (s String) Cursor() *StringCursor { return &StringCursor{s: s, i: -1} }
+
+func (c *StringCursor) Valid() bool { return c.i >= 0 && c.i < len(c.s) }
+
+func (c *StringCursor) decode() bool {
+	if !c.Valid() {
+		c.r, c.n = 0, 0
+		return false
+	}
+	c.r, c.n = utf8.DecodeRuneInString(string(c.s[c.i:]))
+	return true
+}
+
+// Seek moves the cursor to the first rune that starts at or after byte
+// offset i. Offsets within a multi-byte encoding are advanced to the start
+// of the next rune.
+func (c *StringCursor) Seek(i int) bool {
+	i = min(max(i, 0), len(c.s))
+	if i > 0 && i < len(c.s) && !utf8.RuneStart(c.s[i]) {
+		// Find the start of the rune containing i,
+		// and step over that rune if it really does contain i.
+		j := i
+		for j > 0 && i-j < utf8.UTFMax && !utf8.RuneStart(c.s[j]) {
+			j--
+		}
+		if _, n := utf8.DecodeRuneInString(string(c.s[j:])); j+n > i {
+			i = j + n
+		}
+	}
+	c.i = i
+	return c.decode()
+}
+
+func (c *StringCursor) Next() bool {
+	switch {
+	case c.i < 0:
+		c.i = 0
+	case c.i < len(c.s):
+		c.i += c.n
+	}
+	return c.decode()
+}
+
+func (c *StringCursor) Prev() bool {
+	if c.i <= 0 {
+		c.i = -1
+		return c.decode()
+	}
+	_, n := utf8.DecodeLastRuneInString(string(c.s[:c.i]))
+	c.i -= n
+	return c.decode()
+}
+
+func (c *StringCursor) Key() int {
+	if !c.Valid() {
+		return 0
+	}
+	return c.i
+}
+
+func (c *StringCursor) Value() rune { return c.r }
+
+// A ListCursor is a Cursor over the elements of a List, keyed by Element.
+type ListCursor[T any] struct {
+	l      *List[T]
+	e      *Element[T] // nil if not Valid
+	before bool        // if e is nil, whether the cursor is before the first element
+}
+
+// Cursor returns a new Cursor positioned before the first element of l.
+func (l *List[T]) Cursor() *ListCursor[T] { return &ListCursor[T]{l: l, before: true} }
+
+func (c *ListCursor[T]) Valid() bool { return c.e != nil && c.e.list == c.l }
+
+// Seek moves the cursor to e, which must be an element of the list.
+// If it is not, the cursor moves after the last element.
+func (c *ListCursor[T]) Seek(e *Element[T]) bool {
+	c.e, c.before = nil, false
+	if e != nil && e.list == c.l {
+		c.e = e
+	}
+	return c.Valid()
+}
+
+func (c *ListCursor[T]) Next() bool {
+	switch {
+	case c.Valid():
+		c.e = c.e.Next()
+	case c.before:
+		c.e = c.l.Front()
+	}
+	c.before = false
+	return c.Valid()
+}
+
+func (c *ListCursor[T]) Prev() bool {
+	switch {
+	case c.Valid():
+		c.e = c.e.Prev()
+	case !c.before:
+		c.e = c.l.Back()
+	}
+	c.before = c.e == nil
+	return c.Valid()
+}
+
+func (c *ListCursor[T]) Key() *Element[T] {
+	if !c.Valid() {
+		return nil
+	}
+	return c.e
+}
+
+func (c *ListCursor[T]) Value() T {
+	if !c.Valid() {
+		return *new(T)
+	}
+	return c.e.Value
+}
+
+// A MatrixCursor is a Cursor over the elements of a Matrix in row-major
+// order, keyed by [2]int{row, column}.
+type MatrixCursor[T any] struct {
+	m Matrix[T]
+	i int // row-major position: -1 before the first element, m.Len() after the last
+}
+
+// Cursor returns a new Cursor positioned before the first element of m.
+func (m Matrix[T]) Cursor() *MatrixCursor[T] { return &MatrixCursor[T]{m: m, i: -1} }
+
+func (c *MatrixCursor[T]) Valid() bool { return c.i >= 0 && c.i < c.m.Len() }
+
+func (c *MatrixCursor[T]) Seek(ij [2]int) bool {
+	switch {
+	case ij[0] < 0:
+		c.i = 0
+	case ij[0] >= c.m.rows:
+		c.i = c.m.Len()
+	default:
+		c.i = ij[0]*c.m.cols + min(max(ij[1], 0), c.m.cols)
+	}
+	return c.Valid()
+}
+
+func (c *MatrixCursor[T]) Next() bool {
+	if c.i < c.m.Len() {
+		c.i++
+	}
+	return c.Valid()
+}
+
+func (c *MatrixCursor[T]) Prev() bool {
+	if c.i >= 0 {
+		c.i--
+	}
+	return c.Valid()
+}
+
+func (c *MatrixCursor[T]) Key() [2]int {
+	if !c.Valid() {
+		return [2]int{}
+	}
+	return [2]int{c.i / c.m.cols, c.i % c.m.cols}
+}
+
+func (c *MatrixCursor[T]) Value() T {
+	if !c.Valid() {
+		return *new(T)
+	}
+	return c.m.data[c.m.offset(c.i/c.m.cols, c.i%c.m.cols)]
+}
+
+// Cursor returns a new Cursor positioned before the first element of the
+// current snapshot of s.
+func (s *COWSlice[T]) Cursor() *SliceCursor[T] { return s.Load().Cursor() }
@@ -0,0 +1,170 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Cursor[int, int]                      = (*containers.SliceCursor[int])(nil)
+	_ containers.Cursor[int, rune]                     = (*containers.StringCursor)(nil)
+	_ containers.Cursor[*containers.Element[int], int] = (*containers.ListCursor[int])(nil)
+	_ containers.Cursor[[2]int, int]                   = (*containers.MatrixCursor[int])(nil)
+)
+
+// checkCursor checks that c, a new Cursor, visits the given keys and values
+// in order with Next, and in reverse with Prev, and that it stays invalid
+// with zero Key and Value at either end.
+func checkCursor[K, V comparable](t *testing.T, name string, c containers.Cursor[K, V], keys []K, vals []V) {
+	t.Helper()
+	checkInvalid := func(where string) {
+		t.Helper()
+		if c.Valid() || c.Key() != *new(K) || c.Value() != *new(V) {
+			t.Errorf("%s: %s: Valid, Key, Value = %v, %v, %v; want false, zero, zero", name, where, c.Valid(), c.Key(), c.Value())
+		}
+	}
+	check := func(i int, ok bool, op string) {
+		t.Helper()
+		if !ok || !c.Valid() || c.Key() != keys[i] || c.Value() != vals[i] {
+			t.Errorf("%s: %s to entry %d: %v, Key %v, Value %v; want true, %v, %v", name, op, i, ok, c.Key(), c.Value(), keys[i], vals[i])
+		}
+	}
+
+	checkInvalid("new cursor")
+	if c.Prev() {
+		t.Errorf("%s: Prev before the first entry reported true", name)
+	}
+	for i := range keys {
+		check(i, c.Next(), "Next")
+	}
+	for i := 0; i < 2; i++ {
+		if c.Next() {
+			t.Errorf("%s: Next after the last entry reported true", name)
+		}
+		checkInvalid("after the last entry")
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		check(i, c.Prev(), "Prev")
+	}
+	if c.Prev() {
+		t.Errorf("%s: Prev from the first entry reported true", name)
+	}
+	checkInvalid("before the first entry")
+	if len(keys) > 0 {
+		check(0, c.Next(), "Next")
+	}
+}
+
+func TestSliceCursor(t *testing.T) {
+	s := containers.Slice[string]{"a", "b", "c"}
+	checkCursor[int, string](t, "Slice", s.Cursor(), []int{0, 1, 2}, []string{"a", "b", "c"})
+	checkCursor[int, string](t, "empty Slice", containers.Slice[string]{}.Cursor(), nil, nil)
+
+	c := s.Cursor()
+	if !c.Seek(-5) || c.Key() != 0 {
+		t.Errorf("Seek(-5): Key() = %d; want 0", c.Key())
+	}
+	if !c.Seek(2) || c.Value() != "c" {
+		t.Errorf("Seek(2): Value() = %q; want c", c.Value())
+	}
+	if c.Seek(3) {
+		t.Errorf("Seek(3) reported true")
+	}
+	if !c.Prev() || c.Key() != 2 {
+		t.Errorf("Prev after Seek(3): Key() = %d; want 2", c.Key())
+	}
+}
+
+func TestStringCursor(t *testing.T) {
+	s := containers.String("a世b😀")
+	checkCursor[int, rune](t, "String", s.Cursor(), []int{0, 1, 4, 5}, []rune{'a', '世', 'b', '😀'})
+	checkCursor[int, rune](t, "empty String", containers.String("").Cursor(), nil, nil)
+
+	c := s.Cursor()
+	for _, tc := range []struct {
+		seek, key int
+		ok        bool
+	}{
+		{-1, 0, true},
+		{1, 1, true},
+		{2, 4, true}, // within 世: advance to the next rune
+		{3, 4, true},
+		{6, 0, false}, // within 😀, the last rune
+		{9, 0, false},
+	} {
+		if ok := c.Seek(tc.seek); ok != tc.ok || c.Key() != tc.key {
+			t.Errorf("Seek(%d) = %v, Key() = %d; want %v, %d", tc.seek, ok, c.Key(), tc.ok, tc.key)
+		}
+	}
+	if !c.Prev() || c.Value() != '😀' {
+		t.Errorf("Prev after Seek past the end: Value() = %q; want 😀", c.Value())
+	}
+
+	// Invalid encodings are visited a byte at a time, as by String.Range.
+	checkCursor[int, rune](t, "invalid String", containers.String("a\xffb").Cursor(), []int{0, 1, 2}, []rune{'a', '�', 'b'})
+}
+
+func TestListCursor(t *testing.T) {
+	l := containers.NewList(1, 2, 3)
+	e1 := l.Front()
+	e2 := e1.Next()
+	e3 := e2.Next()
+	checkCursor[*containers.Element[int], int](t, "List", l.Cursor(), []*containers.Element[int]{e1, e2, e3}, []int{1, 2, 3})
+	checkCursor[*containers.Element[int], int](t, "empty List", containers.NewList[int]().Cursor(), nil, nil)
+
+	c := l.Cursor()
+	if !c.Seek(e2) || c.Value() != 2 {
+		t.Errorf("Seek(e2): Value() = %d; want 2", c.Value())
+	}
+
+	// A cursor at a removed element is no longer valid.
+	l.Remove(e2)
+	if c.Valid() || c.Key() != nil {
+		t.Errorf("cursor at a removed element: Valid, Key = %v, %v; want false, nil", c.Valid(), c.Key())
+	}
+
+	other := containers.NewList(4)
+	if c.Seek(other.Front()) {
+		t.Errorf("Seek to an element of another list reported true")
+	}
+	if !c.Prev() || c.Key() != e3 {
+		t.Errorf("Prev after failed Seek: Key() = %p; want the last element %p", c.Key(), e3)
+	}
+}
+
+func TestMatrixCursor(t *testing.T) {
+	m := containers.MatrixOf(2, 3, []int{
+		1, 2, 3,
+		4, 5, 6,
+	})
+	keys := [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}}
+	checkCursor[[2]int, int](t, "Matrix", m.Cursor(), keys, []int{1, 2, 3, 4, 5, 6})
+	checkCursor[[2]int, int](t, "transposed Matrix", m.Transpose().Cursor(),
+		[][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}, {2, 1}}, []int{1, 4, 2, 5, 3, 6})
+
+	c := m.Cursor()
+	if !c.Seek([2]int{1, -1}) || c.Key() != [2]int{1, 0} {
+		t.Errorf("Seek([1 -1]): Key() = %v; want [1 0]", c.Key())
+	}
+	if !c.Seek([2]int{0, 3}) || c.Key() != [2]int{1, 0} {
+		t.Errorf("Seek([0 3]): Key() = %v; want [1 0]", c.Key())
+	}
+	if c.Seek([2]int{2, 0}) {
+		t.Errorf("Seek([2 0]) reported true")
+	}
+}
+
+func TestCOWSliceCursor(t *testing.T) {
+	var s containers.COWSlice[int]
+	s.Append(1, 2)
+	c := s.Cursor()
+	s.Append(3)
+
+	// The cursor iterates over the snapshot from which it was created.
+	checkCursor[int, int](t, "COWSlice", c, []int{0, 1}, []int{1, 2})
+}
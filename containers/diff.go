@@ -0,0 +1,286 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A Delta describes the differences between two keyed containers.
+type Delta[K comparable, V any] struct {
+	Added   Map[K, V]         // entries present only in the new container
+	Removed Map[K, V]         // entries present only in the old container
+	Changed Map[K, Change[V]] // entries present in both, with unequal values
+}
+
+// A Change records the old and new values of an entry.
+type Change[V any] struct {
+	Old, New V
+}
+
+// Empty reports whether d describes no differences.
+func (d Delta[K, V]) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff returns the differences between old and updated.
+func Diff[K, V comparable](old, updated Ranger[K, V]) Delta[K, V] {
+	return DiffFunc(old, updated, func(a, b V) bool { return a == b })
+}
+
+// DiffFunc is like Diff, but compares values using eq.
+func DiffFunc[K comparable, V any](old, updated Ranger[K, V], eq func(V, V) bool) Delta[K, V] {
+	d := Delta[K, V]{
+		Added:   make(Map[K, V]),
+		Removed: make(Map[K, V]),
+		Changed: make(Map[K, Change[V]]),
+	}
+	next := make(map[K]V)
+	updated.Range(func(k K, v V) bool {
+		next[k] = v
+		return true
+	})
+	old.Range(func(k K, v V) bool {
+		nv, ok := next[k]
+		switch {
+		case !ok:
+			d.Removed[k] = v
+		case !eq(v, nv):
+			d.Changed[k] = Change[V]{Old: v, New: nv}
+		}
+		delete(next, k)
+		return true
+	})
+	for k, v := range next {
+		d.Added[k] = v
+	}
+	return d
+}
+
+// Patch applies d to dst, which should contain the old entries of d.
+//
+// If d removes any entries, dst must also implement Deleter[K];
+// otherwise, Patch returns an error without modifying dst.
+func Patch[K comparable, V any](dst IndexSetter[K, V], d Delta[K, V]) error {
+	if len(d.Removed) > 0 {
+		del, ok := dst.(Deleter[K])
+		if !ok {
+			return fmt.Errorf("Patch: %T does not implement Deleter", dst)
+		}
+		for k := range d.Removed {
+			del.Delete(k)
+		}
+	}
+	for k, c := range d.Changed {
+		dst.SetIndex(k, c.New)
+	}
+	for k, v := range d.Added {
+		dst.SetIndex(k, v)
+	}
+	return nil
+}
+
+// An EditOp is the kind of operation in an edit script.
+type EditOp int
+
+const (
+	EditKeep   EditOp = iota // keep Old[OldIndex], which equals New[NewIndex]
+	EditDelete               // delete Old[OldIndex]
+	EditInsert               // insert New[NewIndex] before Old[OldIndex]
+)
+
+func (op EditOp) String() string {
+	switch op {
+	case EditKeep:
+		return "keep"
+	case EditDelete:
+		return "delete"
+	case EditInsert:
+		return "insert"
+	default:
+		return fmt.Sprintf("EditOp(%d)", int(op))
+	}
+}
+
+// An Edit is one step in an edit script transforming one sequence into another.
+type Edit[V any] struct {
+	Op       EditOp
+	OldIndex int
+	NewIndex int
+	Value    V
+}
+
+// A Sequence is a container indexed by consecutive integers starting at 0,
+// such as a Slice.
+type Sequence[V any] interface {
+	Lenner
+	Indexer[int, V]
+}
+
+// EditScript returns a shortest edit script transforming old into updated,
+// computed using Myers' O(ND) difference algorithm.
+func EditScript[V comparable](old, updated Sequence[V]) []Edit[V] {
+	return EditScriptFunc(old, updated, func(a, b V) bool { return a == b })
+}
+
+// EditScriptFunc is like EditScript, but compares elements using eq.
+//
+// It uses the linear-space refinement of Myers' algorithm, which finds the
+// middle snake of an optimal path and recurses on either side of it, so it
+// requires O(n+m) memory in addition to the result.
+func EditScriptFunc[V any](old, updated Sequence[V], eq func(V, V) bool) []Edit[V] {
+	a, b := seqElems(old), seqElems(updated)
+	diags := len(a) + len(b) + 1
+	d := &differ[V]{
+		a:  a,
+		b:  b,
+		eq: eq,
+		vf: make([]int, 2*diags+1),
+		vb: make([]int, 2*diags+1),
+	}
+	d.compare(0, len(a), 0, len(b))
+	return d.edits
+}
+
+// A differ holds the state of a call to EditScriptFunc.
+type differ[V any] struct {
+	a, b   []V
+	eq     func(V, V) bool
+	vf, vb []int // furthest-reaching forward and backward paths, by diagonal
+	edits  []Edit[V]
+}
+
+func (d *differ[V]) keep(x, y int) {
+	d.edits = append(d.edits, Edit[V]{Op: EditKeep, OldIndex: x, NewIndex: y, Value: d.a[x]})
+}
+
+// compare appends a shortest edit script from a[aLo:aHi] to b[bLo:bHi].
+func (d *differ[V]) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.eq(d.a[aLo], d.b[bLo]) {
+		d.keep(aLo, bLo)
+		aLo, bLo = aLo+1, bLo+1
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.eq(d.a[aHi-suffix-1], d.b[bHi-suffix-1]) {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, Edit[V]{Op: EditInsert, OldIndex: aLo, NewIndex: y, Value: d.b[y]})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, Edit[V]{Op: EditDelete, OldIndex: x, NewIndex: bLo, Value: d.a[x]})
+		}
+	default:
+		x0, y0, x1, y1 := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, aLo+x0, bLo, bLo+y0)
+		for x, y := x0, y0; x < x1; x, y = x+1, y+1 {
+			d.keep(aLo+x, bLo+y)
+		}
+		d.compare(aLo+x1, aHi, bLo+y1, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.keep(aHi+i, bHi+i)
+	}
+}
+
+// middleSnake returns the endpoints, relative to (aLo, bLo), of the middle
+// snake of a shortest path from a[aLo:aHi] to b[bLo:bHi], both of which must
+// be non-empty.
+func (d *differ[V]) middleSnake(aLo, aHi, bLo, bHi int) (x0, y0, x1, y1 int) {
+	a, b := d.a[aLo:aHi], d.b[bLo:bHi]
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	offset := (n+m+1)/2 + 1
+	vf, vb := d.vf[:2*offset+1], d.vb[:2*offset+1]
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for D := 0; D <= (n+m+1)/2; D++ {
+		// Forward paths, in which x and y count elements consumed from the start.
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.eq(a[x], b[y]) {
+				x, y = x+1, y+1
+			}
+			vf[offset+k] = x
+			if kb := delta - k; odd && -(D-1) <= kb && kb <= D-1 && x+vb[offset+kb] >= n {
+				return sx, sy, x, y
+			}
+		}
+
+		// Backward paths, in which x and y count elements consumed from the end.
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || (k != D && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.eq(a[n-x-1], b[m-y-1]) {
+				x, y = x+1, y+1
+			}
+			vb[offset+k] = x
+			if kf := delta - k; !odd && -D <= kf && kf <= D && x+vf[offset+kf] >= n {
+				return n - x, m - y, n - sx, m - sy
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+func seqElems[V any](s Sequence[V]) []V {
+	if s, ok := s.(Slice[V]); ok {
+		return s
+	}
+	xs := make([]V, s.Len())
+	for i := range xs {
+		xs[i], _ = s.Index(i)
+	}
+	return xs
+}
+
+// PatchSequence applies an edit script computed by EditScript to old,
+// and returns the resulting sequence.
+func PatchSequence[V any](old Sequence[V], edits []Edit[V]) (Slice[V], error) {
+	a := seqElems(old)
+	out := make(Slice[V], 0, len(a))
+	i := 0
+	for _, e := range edits {
+		if e.OldIndex != i {
+			return nil, errors.New("PatchSequence: edit script does not match sequence")
+		}
+		switch e.Op {
+		case EditKeep:
+			out = append(out, a[i])
+			i++
+		case EditDelete:
+			i++
+		case EditInsert:
+			out = append(out, e.Value)
+		default:
+			return nil, fmt.Errorf("PatchSequence: unknown %v", e.Op)
+		}
+	}
+	if i != len(a) {
+		return nil, errors.New("PatchSequence: edit script does not cover sequence")
+	}
+	return out, nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// lcsLen returns the length of the longest common subsequence of a and b.
+func lcsLen(a, b []byte) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestDiff(t *testing.T) {
+	type delta = containers.Delta[string, int]
+	type change = containers.Change[int]
+
+	for _, tc := range []struct {
+		desc         string
+		old, updated containers.Map[string, int]
+		want         delta
+	}{
+		{
+			desc: "empty",
+			want: delta{},
+		},
+		{
+			desc:    "equal",
+			old:     containers.Map[string, int]{"a": 1, "b": 2},
+			updated: containers.Map[string, int]{"a": 1, "b": 2},
+			want:    delta{},
+		},
+		{
+			desc:    "added",
+			old:     containers.Map[string, int]{"a": 1},
+			updated: containers.Map[string, int]{"a": 1, "b": 2},
+			want:    delta{Added: containers.Map[string, int]{"b": 2}},
+		},
+		{
+			desc:    "removed",
+			old:     containers.Map[string, int]{"a": 1, "b": 2},
+			updated: containers.Map[string, int]{"b": 2},
+			want:    delta{Removed: containers.Map[string, int]{"a": 1}},
+		},
+		{
+			desc:    "changed",
+			old:     containers.Map[string, int]{"a": 1, "b": 2},
+			updated: containers.Map[string, int]{"a": 1, "b": 3},
+			want:    delta{Changed: containers.Map[string, change]{"b": {Old: 2, New: 3}}},
+		},
+		{
+			desc:    "mixed",
+			old:     containers.Map[string, int]{"a": 1, "b": 2, "c": 3},
+			updated: containers.Map[string, int]{"b": 20, "c": 3, "d": 4},
+			want: delta{
+				Added:   containers.Map[string, int]{"d": 4},
+				Removed: containers.Map[string, int]{"a": 1},
+				Changed: containers.Map[string, change]{"b": {Old: 2, New: 20}},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			d := containers.Diff[string, int](tc.old, tc.updated)
+			checkDelta(t, d, tc.want)
+			if got := d.Empty(); got != tc.want.Empty() {
+				t.Errorf("Empty() = %v; want %v", got, tc.want.Empty())
+			}
+
+			dst := maps.Clone(tc.old)
+			if dst == nil {
+				dst = make(containers.Map[string, int])
+			}
+			if err := containers.Patch[string, int](dst, d); err != nil {
+				t.Fatalf("Patch: %v", err)
+			}
+			if !maps.Equal(dst, tc.updated) {
+				t.Errorf("Patch(%v, Diff(%v, %v)) = %v", tc.old, tc.old, tc.updated, dst)
+			}
+		})
+	}
+}
+
+func checkDelta(t *testing.T, got, want containers.Delta[string, int]) {
+	t.Helper()
+	if !maps.Equal(got.Added, want.Added) {
+		t.Errorf("Added = %v; want %v", got.Added, want.Added)
+	}
+	if !maps.Equal(got.Removed, want.Removed) {
+		t.Errorf("Removed = %v; want %v", got.Removed, want.Removed)
+	}
+	if !maps.Equal(got.Changed, want.Changed) {
+		t.Errorf("Changed = %v; want %v", got.Changed, want.Changed)
+	}
+}
+
+func TestDiffFunc(t *testing.T) {
+	// Compare values modulo 10, so that 1 and 11 are considered equal.
+	eq := func(a, b int) bool { return a%10 == b%10 }
+	old := containers.Map[string, int]{"a": 1, "b": 2}
+	updated := containers.Map[string, int]{"a": 11, "b": 3}
+
+	d := containers.DiffFunc[string, int](old, updated, eq)
+	checkDelta(t, d, containers.Delta[string, int]{
+		Changed: containers.Map[string, containers.Change[int]]{"b": {Old: 2, New: 3}},
+	})
+}
+
+func TestPatchNotDeleter(t *testing.T) {
+	old := containers.Slice[int]{1, 2, 3}
+	updated := containers.Slice[int]{1, 20}
+	d := containers.Diff[int, int](old, updated)
+
+	dst := slices.Clone(old)
+	if err := containers.Patch[int, int](dst, d); err == nil {
+		t.Fatalf("Patch(%v, %+v) succeeded; want error for non-Deleter", dst, d)
+	}
+	if !slices.Equal(dst, old) {
+		t.Errorf("Patch modified dst to %v after failing; want %v", dst, old)
+	}
+
+	// Without removals, Patch does not require a Deleter.
+	d = containers.Diff[int, int](old, containers.Slice[int]{1, 20, 3})
+	if err := containers.Patch[int, int](dst, d); err != nil {
+		t.Fatalf("Patch without removals: %v", err)
+	}
+	if want := []int{1, 20, 3}; !slices.Equal(dst, want) {
+		t.Errorf("Patch(%v, %+v) = %v; want %v", old, d, dst, want)
+	}
+}
+
+func checkEditScript(t *testing.T, a, b []byte) {
+	t.Helper()
+	edits := containers.EditScript[byte](containers.Slice[byte](a), containers.Slice[byte](b))
+
+	got, err := containers.PatchSequence[byte](containers.Slice[byte](a), edits)
+	if err != nil {
+		t.Fatalf("PatchSequence(%q, EditScript(%q, %q)): %v", a, a, b, err)
+	}
+	if !slices.Equal(got, b) {
+		t.Fatalf("PatchSequence(%q, EditScript(%q, %q)) = %q", a, a, b, got)
+	}
+
+	changes := 0
+	for _, e := range edits {
+		if e.Op != containers.EditKeep {
+			changes++
+		}
+	}
+	if want := len(a) + len(b) - 2*lcsLen(a, b); changes != want {
+		t.Fatalf("EditScript(%q, %q) has %d changes; want %d", a, b, changes, want)
+	}
+}
+
+func TestEditScript(t *testing.T) {
+	checkEditScript(t, nil, nil)
+	checkEditScript(t, nil, []byte("abc"))
+	checkEditScript(t, []byte("abc"), nil)
+	checkEditScript(t, []byte("abcabba"), []byte("abcabba"))
+	checkEditScript(t, []byte("abcabba"), []byte("cbabac"))
+
+	r := rand.New(rand.NewSource(1))
+	randBytes := func() []byte {
+		b := make([]byte, r.Intn(40))
+		for i := range b {
+			b[i] = "abcd"[r.Intn(4)]
+		}
+		return b
+	}
+	for i := 0; i < 2000; i++ {
+		checkEditScript(t, randBytes(), randBytes())
+	}
+}
+
+func TestEditScriptDisjoint(t *testing.T) {
+	// Disjoint inputs take the maximum number of rounds,
+	// which must not require quadratic memory.
+	const n = 5000
+	a, b := make(containers.Slice[int], n), make(containers.Slice[int], n)
+	for i := range a {
+		a[i], b[i] = i, n+i
+	}
+	edits := containers.EditScript[int](a, b)
+	if len(edits) != 2*n {
+		t.Fatalf("EditScript of disjoint inputs has %d edits; want %d", len(edits), 2*n)
+	}
+}
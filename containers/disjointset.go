@@ -0,0 +1,132 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+// A DisjointSet partitions elements into disjoint sets (a union-find
+// structure). Elements are added by Add, or implicitly the first time they
+// are passed to Union, each in a set of its own. The query methods Find,
+// Connected, and SetSize never add elements.
+//
+// Find uses path compression and Union uses union by rank, so any sequence
+// of operations takes nearly linear time overall.
+// The zero DisjointSet is empty and ready to use.
+type DisjointSet[T comparable] struct {
+	index  map[T]int
+	elems  []T
+	parent []int
+	rank   []uint8
+	size   []int // valid only for roots
+	sets   int
+}
+
+// id returns the index of x, adding it as a singleton if it is new.
+func (d *DisjointSet[T]) id(x T) int {
+	if i, ok := d.index[x]; ok {
+		return i
+	}
+	if d.index == nil {
+		d.index = make(map[T]int)
+	}
+	i := len(d.elems)
+	d.index[x] = i
+	d.elems = append(d.elems, x)
+	d.parent = append(d.parent, i)
+	d.rank = append(d.rank, 0)
+	d.size = append(d.size, 1)
+	d.sets++
+	return i
+}
+
+// lookup returns the index of the root of the set containing x,
+// or -1 if x is not in d.
+func (d *DisjointSet[T]) lookup(x T) int {
+	i, ok := d.index[x]
+	if !ok {
+		return -1
+	}
+	return d.root(i)
+}
+
+func (d *DisjointSet[T]) root(i int) int {
+	r := i
+	for d.parent[r] != r {
+		r = d.parent[r]
+	}
+	for d.parent[i] != r {
+		d.parent[i], i = r, d.parent[i]
+	}
+	return r
+}
+
+// Add adds x as a singleton set, if it is not already present.
+func (d *DisjointSet[T]) Add(x T) { d.id(x) }
+
+// Len returns the number of elements in d.
+func (d *DisjointSet[T]) Len() int         { return len(d.elems) }
+func (d *DisjointSet[T]) SizeBytes() int64 { return referencedSize(d) }
+
+// Sets returns the number of disjoint sets in d.
+func (d *DisjointSet[T]) Sets() int { return d.sets }
+
+// Find returns the representative element of the set containing x,
+// and reports whether x is in d.
+func (d *DisjointSet[T]) Find(x T) (T, bool) {
+	r := d.lookup(x)
+	if r < 0 {
+		return *new(T), false
+	}
+	return d.elems[r], true
+}
+
+// Union merges the sets containing x and y, and reports whether they were
+// previously disjoint.
+func (d *DisjointSet[T]) Union(x, y T) bool {
+	rx, ry := d.root(d.id(x)), d.root(d.id(y))
+	if rx == ry {
+		return false
+	}
+	if d.rank[rx] < d.rank[ry] {
+		rx, ry = ry, rx
+	}
+	d.parent[ry] = rx
+	d.size[rx] += d.size[ry]
+	if d.rank[rx] == d.rank[ry] {
+		d.rank[rx]++
+	}
+	d.sets--
+	return true
+}
+
+// Connected reports whether x and y are in the same set.
+// It reports false if either is not in d.
+func (d *DisjointSet[T]) Connected(x, y T) bool {
+	rx := d.lookup(x)
+	return rx >= 0 && rx == d.lookup(y)
+}
+
+// SetSize returns the number of elements in the set containing x,
+// or 0 if x is not in d.
+func (d *DisjointSet[T]) SetSize(x T) int {
+	r := d.lookup(x)
+	if r < 0 {
+		return 0
+	}
+	return d.size[r]
+}
+
+// Groups returns the sets of d, as a Map from each set's representative
+// to its members.
+func (d *DisjointSet[T]) Groups() Map[T, Slice[T]] {
+	groups := make(Map[T, Slice[T]], d.sets)
+	for i, x := range d.elems {
+		r := d.elems[d.root(i)]
+		g := groups[r]
+		if g == nil {
+			g = make(Slice[T], 0, d.size[d.root(i)])
+		}
+		groups[r] = append(g, x)
+	}
+	return groups
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+func TestDisjointSet(t *testing.T) {
+	var d containers.DisjointSet[string]
+	d.Add("a")
+	d.Add("a")
+	if !d.Union("a", "b") {
+		t.Errorf(`Union("a", "b") = false; want true`)
+	}
+	d.Union("c", "d")
+	d.Union("b", "d")
+	if d.Union("a", "c") {
+		t.Errorf(`Union("a", "c") of connected elements = true; want false`)
+	}
+	d.Add("e")
+
+	if d.Len() != 5 || d.Sets() != 2 {
+		t.Errorf("Len(), Sets() = %d, %d; want 5, 2", d.Len(), d.Sets())
+	}
+	if !d.Connected("a", "d") || d.Connected("a", "e") {
+		t.Errorf(`Connected("a", "d"), Connected("a", "e") = %v, %v; want true, false`,
+			d.Connected("a", "d"), d.Connected("a", "e"))
+	}
+	if n := d.SetSize("c"); n != 4 {
+		t.Errorf(`SetSize("c") = %d; want 4`, n)
+	}
+	ra, _ := d.Find("a")
+	if rd, ok := d.Find("d"); !ok || rd != ra {
+		t.Errorf(`Find("d") = %q, %v; want %q, true`, rd, ok, ra)
+	}
+
+	groups := d.Groups()
+	if len(groups) != 2 {
+		t.Fatalf("Groups() has %d sets; want 2", len(groups))
+	}
+	g := slices.Clone(groups[ra])
+	slices.Sort(g)
+	if want := []string{"a", "b", "c", "d"}; !slices.Equal(g, want) {
+		t.Errorf("Groups()[%q] = %v; want %v", ra, g, want)
+	}
+	if g := groups["e"]; !slices.Equal(g, []string{"e"}) {
+		t.Errorf(`Groups()["e"] = %v; want [e]`, g)
+	}
+}
+
+func TestDisjointSetUnknown(t *testing.T) {
+	var d containers.DisjointSet[int]
+	d.Add(1)
+
+	// Queries about unknown elements do not add them.
+	if x, ok := d.Find(2); ok {
+		t.Errorf("Find(2) = %d, true; want false", x)
+	}
+	if d.Connected(2, 2) || d.Connected(1, 2) || d.Connected(2, 1) {
+		t.Errorf("Connected reported true for an unknown element")
+	}
+	if n := d.SetSize(2); n != 0 {
+		t.Errorf("SetSize(2) = %d; want 0", n)
+	}
+	if d.Len() != 1 || d.Sets() != 1 {
+		t.Errorf("after queries, Len(), Sets() = %d, %d; want 1, 1", d.Len(), d.Sets())
+	}
+	if _, ok := d.Groups()[2]; ok {
+		t.Errorf("Groups() contains an unknown element")
+	}
+
+	// Union adds elements.
+	d.Union(2, 3)
+	if d.Len() != 3 || d.Sets() != 2 {
+		t.Errorf("after Union, Len(), Sets() = %d, %d; want 3, 2", d.Len(), d.Sets())
+	}
+}
+
+func TestDisjointSetRandom(t *testing.T) {
+	// Check against a naive labelling, relabelling one whole set on each union.
+	const n = 200
+	var d containers.DisjointSet[int]
+	label := make([]int, n)
+	for i := range label {
+		label[i] = i
+		d.Add(i)
+	}
+	r := rand.New(rand.NewSource(1))
+	for step := 0; step < 300; step++ {
+		x, y := r.Intn(n), r.Intn(n)
+		lx, ly := label[x], label[y]
+		if got := d.Union(x, y); got != (lx != ly) {
+			t.Fatalf("Union(%d, %d) = %v; want %v", x, y, got, lx != ly)
+		}
+		for i := range label {
+			if label[i] == ly {
+				label[i] = lx
+			}
+		}
+
+		x, y = r.Intn(n), r.Intn(n)
+		if got, want := d.Connected(x, y), label[x] == label[y]; got != want {
+			t.Fatalf("Connected(%d, %d) = %v; want %v", x, y, got, want)
+		}
+		size := 0
+		for i := range label {
+			if label[i] == label[x] {
+				size++
+			}
+		}
+		if got := d.SetSize(x); got != size {
+			t.Fatalf("SetSize(%d) = %d; want %d", x, got, size)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import "fmt"
+
+// Numeric is a constraint that matches any numeric type.
+type Numeric interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~complex64 | ~complex128
+}
+
+// A FenwickTree (or binary indexed tree) is a sequence of numbers
+// supporting point updates and prefix sums in logarithmic time.
+type FenwickTree[T Numeric] struct {
+	vals []T
+	tree []T // tree[i-1] holds the sum of vals[i-(i&-i) : i]
+}
+
+// NewFenwickTree returns a FenwickTree of n zeroes.
+func NewFenwickTree[T Numeric](n int) *FenwickTree[T] {
+	return &FenwickTree[T]{vals: make([]T, n), tree: make([]T, n)}
+}
+
+// FenwickTreeOf returns a FenwickTree containing a copy of xs.
+func FenwickTreeOf[T Numeric](xs []T) *FenwickTree[T] {
+	f := &FenwickTree[T]{vals: append([]T(nil), xs...), tree: append([]T(nil), xs...)}
+	for i := 1; i <= len(f.tree); i++ {
+		if j := i + (i & -i); j <= len(f.tree) {
+			f.tree[j-1] += f.tree[i-1]
+		}
+	}
+	return f
+}
+
+func (f *FenwickTree[T]) Len() int         { return len(f.vals) }
+func (f *FenwickTree[T]) SizeBytes() int64 { return referencedSize(f) }
+
+func (f *FenwickTree[T]) Index(i int) (T, bool) {
+	if i < 0 || i >= len(f.vals) {
+		return 0, false
+	}
+	return f.vals[i], true
+}
+
+// SetIndex sets the element at index i to x.
+//
+// Unlike Add, SetIndex recomputes the affected sums from the stored elements
+// rather than applying a difference, so that it does not lose precision when
+// replacing a large floating-point value with a small one.
+func (f *FenwickTree[T]) SetIndex(i int, x T) {
+	if i < 0 || i >= len(f.vals) {
+		panic(fmt.Sprintf("index %d out of range [0:%d]", i, len(f.vals)))
+	}
+	f.vals[i] = x
+	for j := i + 1; j <= len(f.tree); j += j & -j {
+		// The children of node j cover vals[j-(j&-j) : j-1],
+		// and have already been recomputed if they include index i.
+		sum := f.vals[j-1]
+		for k := j - 1; k > j-(j&-j); k -= k & -k {
+			sum += f.tree[k-1]
+		}
+		f.tree[j-1] = sum
+	}
+}
+
+// Add adds delta to the element at index i.
+func (f *FenwickTree[T]) Add(i int, delta T) {
+	if i < 0 || i >= len(f.vals) {
+		panic(fmt.Sprintf("index %d out of range [0:%d]", i, len(f.vals)))
+	}
+	f.vals[i] += delta
+	for j := i + 1; j <= len(f.tree); j += j & -j {
+		f.tree[j-1] += delta
+	}
+}
+
+// PrefixSum returns the sum of the first n elements.
+func (f *FenwickTree[T]) PrefixSum(n int) T {
+	if n < 0 || n > len(f.vals) {
+		panic(fmt.Sprintf("PrefixSum: length %d out of range [0:%d]", n, len(f.vals)))
+	}
+	var sum T
+	for j := n; j > 0; j -= j & -j {
+		sum += f.tree[j-1]
+	}
+	return sum
+}
+
+// RangeSum returns the sum of the elements in [lo, hi).
+func (f *FenwickTree[T]) RangeSum(lo, hi int) T {
+	return f.PrefixSum(hi) - f.PrefixSum(lo)
+}
+
+func (f *FenwickTree[T]) RangeKeys(fn func(i int) bool)  { Slice[T](f.vals).RangeKeys(fn) }
+func (f *FenwickTree[T]) RangeElems(fn func(x T) bool)   { Slice[T](f.vals).RangeElems(fn) }
+func (f *FenwickTree[T]) Range(fn func(i int, x T) bool) { Slice[T](f.vals).Range(fn) }
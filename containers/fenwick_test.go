@@ -0,0 +1,209 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.IndexSetter[int, int] = (*containers.FenwickTree[int])(nil)
+	_ containers.Ranger[int, int]      = (*containers.FenwickTree[int])(nil)
+	_ containers.IndexSetter[int, int] = (*containers.SegmentTree[int])(nil)
+	_ containers.Ranger[int, int]      = (*containers.SegmentTree[int])(nil)
+)
+
+func TestFenwickTree(t *testing.T) {
+	f := containers.NewFenwickTree[float64](4)
+	f.Add(1, 1.5)
+	f.SetIndex(3, 2)
+	f.Add(1, 1)
+	if got := f.RangeSum(0, 4); got != 4.5 {
+		t.Errorf("RangeSum(0, 4) = %v; want 4.5", got)
+	}
+	if got := f.RangeSum(2, 4); got != 2 {
+		t.Errorf("RangeSum(2, 4) = %v; want 2", got)
+	}
+}
+
+func TestFenwickTreeSetIndexPrecision(t *testing.T) {
+	// Replacing a large value with a small one must not cancel out
+	// the small values summed alongside it.
+	f := containers.FenwickTreeOf([]float64{1e20, 5})
+	f.SetIndex(0, 1)
+	if got, _ := f.Index(0); got != 1 {
+		t.Errorf("Index(0) = %v; want 1", got)
+	}
+	if got := f.PrefixSum(1); got != 1 {
+		t.Errorf("PrefixSum(1) = %v; want 1", got)
+	}
+	if got := f.PrefixSum(2); got != 6 {
+		t.Errorf("PrefixSum(2) = %v; want 6", got)
+	}
+
+	mustPanic(t, "SetIndex(2, 0)", func() { f.SetIndex(2, 0) })
+}
+
+func TestFenwickTreeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 7, 64, 100} {
+		want := make([]int, n)
+		for i := range want {
+			want[i] = r.Intn(201) - 100
+		}
+		f := containers.FenwickTreeOf(want)
+
+		for step := 0; step < 500; step++ {
+			if n > 0 {
+				i := r.Intn(n)
+				x := r.Intn(201) - 100
+				if r.Intn(2) == 0 {
+					f.Add(i, x)
+					want[i] += x
+				} else {
+					f.SetIndex(i, x)
+					want[i] = x
+				}
+			}
+
+			lo := r.Intn(n + 1)
+			hi := lo + r.Intn(n-lo+1)
+			sum := 0
+			for _, x := range want[lo:hi] {
+				sum += x
+			}
+			if got := f.RangeSum(lo, hi); got != sum {
+				t.Fatalf("n=%d: RangeSum(%d, %d) = %d; want %d", n, lo, hi, got, sum)
+			}
+		}
+
+		for i, x := range want {
+			if got, ok := f.Index(i); !ok || got != x {
+				t.Fatalf("n=%d: Index(%d) = %d, %v; want %d, true", n, i, got, ok, x)
+			}
+		}
+		if _, ok := f.Index(n); ok {
+			t.Fatalf("n=%d: Index(%d) reported ok", n, n)
+		}
+		sum := 0
+		for i, x := range want {
+			if got := f.PrefixSum(i); got != sum {
+				t.Fatalf("n=%d: PrefixSum(%d) = %d; want %d", n, i, got, sum)
+			}
+			sum += x
+		}
+		if got := f.PrefixSum(n); got != sum {
+			t.Fatalf("n=%d: PrefixSum(%d) = %d; want %d", n, n, got, sum)
+		}
+	}
+}
+
+// checkSegmentTree applies random SetRange and SetIndex operations to a
+// SegmentTree and to a plain slice, checking Query and Index against a
+// left-to-right fold of the slice after each one.
+func checkSegmentTree[T comparable](t *testing.T, name string, r *rand.Rand, gen func() T, combine func(T, T) T, identity T) {
+	t.Helper()
+	for _, n := range []int{0, 1, 2, 5, 33, 100} {
+		want := make([]T, n)
+		for i := range want {
+			want[i] = gen()
+		}
+		st := containers.NewSegmentTree(want, combine, identity)
+		want = append([]T(nil), want...)
+
+		for step := 0; step < 500; step++ {
+			if n > 0 {
+				x := gen()
+				if r.Intn(4) == 0 {
+					i := r.Intn(n)
+					st.SetIndex(i, x)
+					want[i] = x
+				} else {
+					lo := r.Intn(n + 1)
+					hi := lo + r.Intn(n-lo+1)
+					st.SetRange(lo, hi, x)
+					for i := lo; i < hi; i++ {
+						want[i] = x
+					}
+				}
+			}
+
+			lo := r.Intn(n + 1)
+			hi := lo + r.Intn(n-lo+1)
+			agg := identity
+			for _, x := range want[lo:hi] {
+				agg = combine(agg, x)
+			}
+			if got := st.Query(lo, hi); got != agg {
+				t.Fatalf("%s, n=%d: Query(%d, %d) = %v; want %v", name, n, lo, hi, got, agg)
+			}
+			if n > 0 {
+				i := r.Intn(n)
+				if got, ok := st.Index(i); !ok || got != want[i] {
+					t.Fatalf("%s, n=%d: Index(%d) = %v, %v; want %v, true", name, n, i, got, ok, want[i])
+				}
+			}
+		}
+
+		i := 0
+		st.Range(func(j int, x T) bool {
+			if j != i || x != want[i] {
+				t.Fatalf("%s, n=%d: Range yielded (%d, %v); want (%d, %v)", name, n, j, x, i, want[i])
+			}
+			i++
+			return true
+		})
+		if i != n {
+			t.Fatalf("%s, n=%d: Range yielded %d elements; want %d", name, n, i, n)
+		}
+	}
+}
+
+func TestSegmentTreeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	small := func() int { return r.Intn(201) - 100 }
+
+	checkSegmentTree(t, "sum", r, small, func(a, b int) int { return a + b }, 0)
+	checkSegmentTree(t, "min", r, small, func(a, b int) int { return min(a, b) }, 1<<31)
+
+	// Concatenation is associative but not commutative,
+	// so it also checks that segments are combined in order.
+	letter := func() string { return string(rune('a' + r.Intn(26))) }
+	checkSegmentTree(t, "concat", r, letter, func(a, b string) string { return a + b }, "")
+}
+
+func TestSegmentTreeConcurrentQuery(t *testing.T) {
+	// Leave pending assignments in the tree, which queries must not push down.
+	st := containers.NewSegmentTree(make([]int, 100), func(a, b int) int { return a + b }, 0)
+	st.SetRange(0, 64, 1)
+	st.SetRange(50, 100, 2)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for lo := 0; lo < 100; lo++ {
+				want := 0
+				for hi := lo + 1; hi <= 100; hi++ {
+					if hi <= 50 {
+						want++
+					} else {
+						want += 2
+					}
+					if got := st.Query(lo, hi); got != want {
+						t.Errorf("Query(%d, %d) = %d; want %d", lo, hi, got, want)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import "math/bits"
+
+// A Hasher maps keys of type K to well-distributed 64-bit hashes.
+//
+// The probabilistic containers only persist and merge correctly if every
+// participating process uses the same Hasher, so a Hasher should be
+// deterministic: unlike hash/maphash, it must not be seeded per process.
+type Hasher[K any] func(K) uint64
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// HashString is a Hasher for strings, using the 64-bit FNV-1a hash
+// followed by a finalizer to spread the low-entropy bits.
+func HashString(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return mix64(h)
+}
+
+// HashBytes is a Hasher for byte slices, consistent with HashString.
+func HashBytes(b []byte) uint64 {
+	h := uint64(fnvOffset64)
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= fnvPrime64
+	}
+	return mix64(h)
+}
+
+// HashUint64 is a Hasher for integer keys.
+func HashUint64(x uint64) uint64 {
+	return mix64(x)
+}
+
+// mix64 is the SplitMix64 finalizer.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// probes returns the two base hashes used for double hashing (Kirsch and
+// Mitzenmacher), so that k independent-enough indices can be derived
+// from a single call to a Hasher.
+func probes(h uint64) (h1, h2 uint64) {
+	h1 = h
+	h2 = bits.RotateLeft64(mix64(h), 32) | 1
+	return h1, h2
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// A HyperLogLog estimates the number of distinct keys that have been added.
+// Its relative standard error is about 1.04/sqrt(2^precision).
+type HyperLogLog[K any] struct {
+	hash Hasher[K]
+	p    uint8
+	reg  []uint8
+}
+
+// NewHyperLogLog returns an empty HyperLogLog with 2^precision registers.
+// The precision must be between 4 and 18, inclusive.
+func NewHyperLogLog[K any](precision int, hash Hasher[K]) *HyperLogLog[K] {
+	if precision < 4 || precision > 18 {
+		panic(fmt.Sprintf("NewHyperLogLog: precision %d out of range [4, 18]", precision))
+	}
+	return &HyperLogLog[K]{
+		hash: hash,
+		p:    uint8(precision),
+		reg:  make([]uint8, 1<<precision),
+	}
+}
+
+// SizeBytes reports the memory used by the HyperLogLog and its registers,
+// not counting any variables captured by its Hasher.
+func (h *HyperLogLog[K]) SizeBytes() int64 { return referencedSize(h) }
+
+// Add adds x to the set of keys.
+func (h *HyperLogLog[K]) Add(x K) {
+	v := h.hash(x)
+	i := v >> (64 - h.p)
+	w := v<<h.p | 1<<(h.p-1) // Guard bit bounds the rank at 64-p+1.
+	if r := uint8(bits.LeadingZeros64(w) + 1); r > h.reg[i] {
+		h.reg[i] = r
+	}
+}
+
+// Count returns the estimated number of distinct keys added.
+func (h *HyperLogLog[K]) Count() uint64 {
+	m := float64(len(h.reg))
+	var sum float64
+	zeros := 0
+	for _, r := range h.reg {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(h.reg) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	est := alpha * m * m / sum
+
+	// For small cardinalities, linear counting is more accurate.
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+	return uint64(est + 0.5)
+}
+
+// Merge adds all of the keys counted by other to h.
+// The two must have the same precision and Hasher.
+func (h *HyperLogLog[K]) Merge(other *HyperLogLog[K]) error {
+	if h.p != other.p {
+		return errors.New("HyperLogLog.Merge: mismatched precision")
+	}
+	for i, r := range other.reg {
+		if r > h.reg[i] {
+			h.reg[i] = r
+		}
+	}
+	return nil
+}
+
+const hyperLogLogMagic = "hl\x01"
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The Hasher is not included in the encoding.
+func (h *HyperLogLog[K]) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, len(hyperLogLogMagic)+1+len(h.reg))
+	b = append(b, hyperLogLogMagic...)
+	b = append(b, h.p)
+	b = append(b, h.reg...)
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The receiver must already have a Hasher (for example, from NewHyperLogLog),
+// and it must be the same Hasher that was used to build the encoded sketch.
+func (h *HyperLogLog[K]) UnmarshalBinary(data []byte) error {
+	if h.hash == nil {
+		return errors.New("HyperLogLog.UnmarshalBinary: receiver has no Hasher")
+	}
+	if len(data) < len(hyperLogLogMagic)+1 || string(data[:len(hyperLogLogMagic)]) != hyperLogLogMagic {
+		return errors.New("HyperLogLog.UnmarshalBinary: invalid encoding")
+	}
+	p := data[len(hyperLogLogMagic)]
+	reg := data[len(hyperLogLogMagic)+1:]
+	if p < 4 || p > 18 || len(reg) != 1<<p {
+		return errors.New("HyperLogLog.UnmarshalBinary: invalid encoding")
+	}
+	for _, r := range reg {
+		if r > 64-p+1 {
+			return errors.New("HyperLogLog.UnmarshalBinary: invalid encoding")
+		}
+	}
+	h.p = p
+	h.reg = append([]uint8(nil), reg...)
+	return nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import "github.com/bcmills/go2go/unsafeslice"
+
+// A Handle is a canonical reference to an interned value.
+// Two Handles from the same Interner are equal if and only if
+// their values are equal, so comparing Handles is a pointer comparison.
+type Handle[T comparable] struct {
+	p *T
+}
+
+// Value returns the interned value.
+func (h Handle[T]) Value() T { return *h.p }
+
+// Intern returns the canonical Handle for x,
+// adding x to the interner if it is not already present.
+func (in *Interner[T]) Intern(x T) Handle[T] {
+	if h, ok := in.load(x); ok {
+		return h
+	}
+	return in.store(x)
+}
+
+// InternBytes returns the canonical Handle for the string with the contents
+// of b. If the string is already interned, InternBytes does not allocate.
+func InternBytes(in *Interner[string], b []byte) Handle[string] {
+	// The zero-copy string is only used for the lookup, and never retained.
+	if h, ok := in.load(unsafeslice.AsString(b)); ok {
+		return h
+	}
+	return in.store(string(b))
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.24
+
+package containers
+
+import "sync"
+
+// An Interner deduplicates values of type T, mapping equal values to a
+// single canonical Handle.
+//
+// Weak references require the weak package from Go 1.24. When built with
+// earlier versions, an Interner retains every value it has interned.
+//
+// The zero Interner is empty and ready to use.
+// An Interner is safe for concurrent use by multiple goroutines.
+type Interner[T comparable] struct {
+	mu sync.Mutex
+	m  map[T]*T
+}
+
+func (in *Interner[T]) load(x T) (Handle[T], bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	p, ok := in.m[x]
+	return Handle[T]{p}, ok
+}
+
+func (in *Interner[T]) store(x T) Handle[T] {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if p, ok := in.m[x]; ok {
+		return Handle[T]{p}
+	}
+	if in.m == nil {
+		in.m = make(map[T]*T)
+	}
+	p := new(T)
+	*p = x
+	in.m[x] = p
+	return Handle[T]{p}
+}
+
+// Len returns the number of entries in the interner.
+func (in *Interner[T]) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.m)
+}
+
+func (in *Interner[T]) SizeBytes() int64 {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return referencedSize(in)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+func TestIntern(t *testing.T) {
+	var in containers.Interner[string]
+	a := in.Intern("hello")
+	b := containers.InternBytes(&in, []byte("hello"))
+	c := in.Intern("world")
+	if a != b {
+		t.Errorf("Intern and InternBytes of equal values returned different Handles")
+	}
+	if a == c {
+		t.Errorf("Intern of different values returned equal Handles")
+	}
+	if a.Value() != "hello" || c.Value() != "world" {
+		t.Errorf("Values = %q, %q; want hello, world", a.Value(), c.Value())
+	}
+	if n := in.Len(); n != 2 {
+		t.Errorf("Len() = %d; want 2", n)
+	}
+	runtime.KeepAlive(b)
+}
+
+func TestInternBytesAllocs(t *testing.T) {
+	var in containers.Interner[string]
+	h := in.Intern("hello")
+	b := []byte("hello")
+	allocs := testing.AllocsPerRun(100, func() {
+		if containers.InternBytes(&in, b) != h {
+			t.Fatal("InternBytes returned a different Handle")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("InternBytes of an interned value made %v allocations; want 0", allocs)
+	}
+	runtime.KeepAlive(h)
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.24
+
+package containers
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// An Interner deduplicates values of type T, mapping equal values to a
+// single canonical Handle.
+//
+// The Interner holds only weak references to its values: once no Handle for
+// a value remains reachable, the value may be garbage-collected and its entry
+// removed. A later Intern of an equal value returns a new Handle.
+//
+// The zero Interner is empty and ready to use.
+// An Interner is safe for concurrent use by multiple goroutines.
+type Interner[T comparable] struct {
+	mu sync.Mutex
+	m  map[T]weak.Pointer[T]
+}
+
+func (in *Interner[T]) load(x T) (Handle[T], bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if p := in.m[x].Value(); p != nil {
+		return Handle[T]{p}, true
+	}
+	return Handle[T]{}, false
+}
+
+func (in *Interner[T]) store(x T) Handle[T] {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if p := in.m[x].Value(); p != nil {
+		return Handle[T]{p}
+	}
+	if in.m == nil {
+		in.m = make(map[T]weak.Pointer[T])
+	}
+	p := new(T)
+	*p = x
+	wp := weak.Make(p)
+	in.m[x] = wp
+	runtime.AddCleanup(p, in.remove, internEntry[T]{x, wp})
+	return Handle[T]{p}
+}
+
+type internEntry[T comparable] struct {
+	x  T
+	wp weak.Pointer[T]
+}
+
+// remove deletes the entry for a collected value, unless it has already
+// been replaced by a newer one.
+func (in *Interner[T]) remove(e internEntry[T]) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.m[e.x] == e.wp {
+		delete(in.m, e.x)
+	}
+}
+
+// Len returns the number of entries in the interner,
+// including any whose values have been collected but not yet removed.
+func (in *Interner[T]) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.m)
+}
+
+func (in *Interner[T]) SizeBytes() int64 {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return referencedSize(in)
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.24
+
+package containers_test
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+func TestInternerCollects(t *testing.T) {
+	var in containers.Interner[string]
+	kept := in.Intern("kept")
+	for i := 0; i < 100; i++ {
+		in.Intern(strconv.Itoa(i)) // The Handle is dropped immediately.
+	}
+	if n := in.Len(); n != 101 {
+		t.Fatalf("Len() = %d; want 101", n)
+	}
+
+	// Cleanups run asynchronously after the values are collected.
+	deadline := time.Now().Add(10 * time.Second)
+	for in.Len() > 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Len() = %d after repeated GC; want 1", in.Len())
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	if h := in.Intern("kept"); h != kept {
+		t.Errorf("Intern of a reachable value returned a new Handle")
+	}
+	runtime.KeepAlive(kept)
+}
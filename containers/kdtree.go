@@ -0,0 +1,235 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// A KDTree is a k-d tree: a spatial index of values at points in
+// N-dimensional space.
+//
+// Points inserted one at a time are not rebalanced, so a KDTree built by
+// Insert from sorted or clustered data can degrade toward a linear scan.
+// For static data, Load builds a balanced tree.
+type KDTree[T any] struct {
+	dims int
+	root *kdNode[T]
+	live int
+	dead int // deleted nodes still in the tree
+}
+
+type kdNode[T any] struct {
+	p           Point
+	v           T
+	axis        int
+	left, right *kdNode[T]
+	deleted     bool
+}
+
+// NewKDTree returns an empty KDTree of points with the given number of
+// dimensions.
+func NewKDTree[T any](dims int) *KDTree[T] {
+	if dims <= 0 {
+		panic(fmt.Sprintf("NewKDTree: invalid dimension %d", dims))
+	}
+	return &KDTree[T]{dims: dims}
+}
+
+func (t *KDTree[T]) Len() int         { return t.live }
+func (t *KDTree[T]) SizeBytes() int64 { return referencedSize(t) }
+
+// Load replaces the contents of t with a balanced tree containing
+// values[i] at points[i] for each i.
+func (t *KDTree[T]) Load(points []Point, values []T) {
+	if len(points) != len(values) {
+		panic(fmt.Sprintf("KDTree.Load: %d points for %d values", len(points), len(values)))
+	}
+	nodes := make([]*kdNode[T], len(points))
+	for i, p := range points {
+		checkDims("KDTree.Load", t.dims, p)
+		nodes[i] = &kdNode[T]{p: p, v: values[i]}
+	}
+	t.build(nodes)
+}
+
+func (t *KDTree[T]) build(nodes []*kdNode[T]) {
+	t.root = t.buildNodes(nodes, 0)
+	t.live = len(nodes)
+	t.dead = 0
+}
+
+func (t *KDTree[T]) buildNodes(nodes []*kdNode[T], depth int) *kdNode[T] {
+	if len(nodes) == 0 {
+		return nil
+	}
+	axis := depth % t.dims
+	slices.SortFunc(nodes, func(a, b *kdNode[T]) int { return cmp.Compare(a.p[axis], b.p[axis]) })
+	m := len(nodes) / 2
+	n := nodes[m]
+	n.axis = axis
+	n.left = t.buildNodes(nodes[:m], depth+1)
+	n.right = t.buildNodes(nodes[m+1:], depth+1)
+	return n
+}
+
+// Insert adds v at point p.
+func (t *KDTree[T]) Insert(p Point, v T) {
+	checkDims("KDTree.Insert", t.dims, p)
+	link := &t.root
+	depth := 0
+	for *link != nil {
+		n := *link
+		if p[n.axis] < n.p[n.axis] {
+			link = &n.left
+		} else {
+			link = &n.right
+		}
+		depth++
+	}
+	*link = &kdNode[T]{p: p, v: v, axis: depth % t.dims}
+	t.live++
+}
+
+// Delete removes one value at point p for which match reports true,
+// or any value at p if match is nil. It reports whether a value was removed.
+func (t *KDTree[T]) Delete(p Point, match func(T) bool) bool {
+	checkDims("KDTree.Delete", t.dims, p)
+	n := t.find(t.root, p, match)
+	if n == nil {
+		return false
+	}
+	n.deleted = true
+	n.v = *new(T)
+	t.live--
+	t.dead++
+	if t.dead > t.live {
+		// Rebuild to discard the deleted nodes and restore balance.
+		nodes := make([]*kdNode[T], 0, t.live)
+		t.walk(t.root, func(n *kdNode[T]) bool {
+			nodes = append(nodes, n)
+			return true
+		})
+		t.build(nodes)
+	}
+	return true
+}
+
+func (t *KDTree[T]) find(n *kdNode[T], p Point, match func(T) bool) *kdNode[T] {
+	for n != nil {
+		if !n.deleted && dist2(n.p, p) == 0 && (match == nil || match(n.v)) {
+			return n
+		}
+		x, split := p[n.axis], n.p[n.axis]
+		if x == split {
+			// Equal coordinates may be on either side after Load.
+			if found := t.find(n.left, p, match); found != nil {
+				return found
+			}
+			n = n.right
+		} else if x < split {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return nil
+}
+
+// walk calls f for each live node under n, stopping early if f returns false.
+func (t *KDTree[T]) walk(n *kdNode[T], f func(*kdNode[T]) bool) bool {
+	if n == nil {
+		return true
+	}
+	if !n.deleted {
+		if !f(n) {
+			return false
+		}
+	}
+	return t.walk(n.left, f) && t.walk(n.right, f)
+}
+
+func (t *KDTree[T]) RangeElems(f func(T) bool) {
+	t.walk(t.root, func(n *kdNode[T]) bool { return f(n.v) })
+}
+
+// Nearest returns the (up to) k values nearest to p,
+// in increasing order of distance.
+func (t *KDTree[T]) Nearest(p Point, k int) Slice[T] {
+	checkDims("KDTree.Nearest", t.dims, p)
+	if k <= 0 {
+		return nil
+	}
+	h := &nearestHeap[T]{k: k}
+	var visit func(n *kdNode[T])
+	visit = func(n *kdNode[T]) {
+		if n == nil {
+			return
+		}
+		if !n.deleted {
+			h.offer(dist2(n.p, p), n.v)
+		}
+		diff := p[n.axis] - n.p[n.axis]
+		near, far := n.left, n.right
+		if diff >= 0 {
+			near, far = far, near
+		}
+		visit(near)
+		if diff*diff <= h.bound() {
+			visit(far)
+		}
+	}
+	visit(t.root)
+	return h.sorted()
+}
+
+// WithinRadius returns the values whose points lie within distance r of p.
+// The query is evaluated lazily each time the result is ranged over.
+func (t *KDTree[T]) WithinRadius(p Point, r float64) ElemRanger[T] {
+	checkDims("KDTree.WithinRadius", t.dims, p)
+	r2 := r * r
+	return spatialQuery[T](func(f func(T) bool) {
+		var visit func(n *kdNode[T]) bool
+		visit = func(n *kdNode[T]) bool {
+			if n == nil {
+				return true
+			}
+			if !n.deleted && dist2(n.p, p) <= r2 && !f(n.v) {
+				return false
+			}
+			split := n.p[n.axis]
+			if p[n.axis]-r <= split && !visit(n.left) {
+				return false
+			}
+			return p[n.axis]+r < split || visit(n.right)
+		}
+		visit(t.root)
+	})
+}
+
+// Intersecting returns the values whose points lie within rect.
+// The query is evaluated lazily each time the result is ranged over.
+func (t *KDTree[T]) Intersecting(rect Rect) ElemRanger[T] {
+	checkRectDims("KDTree.Intersecting", t.dims, rect)
+	return spatialQuery[T](func(f func(T) bool) {
+		var visit func(n *kdNode[T]) bool
+		visit = func(n *kdNode[T]) bool {
+			if n == nil {
+				return true
+			}
+			if !n.deleted && rect.dist2(n.p) == 0 && !f(n.v) {
+				return false
+			}
+			split := n.p[n.axis]
+			if rect.Min[n.axis] <= split && !visit(n.left) {
+				return false
+			}
+			return rect.Max[n.axis] < split || visit(n.right)
+		}
+		visit(t.root)
+	})
+}
@@ -0,0 +1,316 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import "unsafe"
+
+// An Element is an element of a List.
+//
+// An Element remains valid, and continues to refer to the same value,
+// across insertions and removals of other elements, and across moves
+// within or between lists.
+type Element[T any] struct {
+	Value T
+
+	next, prev *Element[T]
+	list       *List[T]
+}
+
+// Next returns the next list element or nil.
+func (e *Element[T]) Next() *Element[T] {
+	if p := e.next; e.list != nil && p != &e.list.root {
+		return p
+	}
+	return nil
+}
+
+// Prev returns the previous list element or nil.
+func (e *Element[T]) Prev() *Element[T] {
+	if p := e.prev; e.list != nil && p != &e.list.root {
+		return p
+	}
+	return nil
+}
+
+// A List is a doubly linked list, like container/list but with typed values.
+// The zero List is empty and ready to use.
+type List[T any] struct {
+	root Element[T] // sentinel: root.next is the front, root.prev the back
+	len  int
+}
+
+// NewList returns a new list containing xs.
+func NewList[T any](xs ...T) *List[T] {
+	l := new(List[T])
+	for _, x := range xs {
+		l.PushBack(x)
+	}
+	return l
+}
+
+func (l *List[T]) lazyInit() {
+	if l.root.next == nil {
+		l.root.next = &l.root
+		l.root.prev = &l.root
+	}
+}
+
+func (l *List[T]) Len() int { return l.len }
+
+func (l *List[T]) SizeBytes() int64 {
+	// Walk the elements directly: through their prev and next pointers, the
+	// estimator would count the sentinel root as a separate Element.
+	var e sizeEstimator
+	n := int64(unsafe.Sizeof(*l))
+	for x := l.Front(); x != nil; x = x.Next() {
+		n += int64(unsafe.Sizeof(*x)) + referencedAt(&e, &x.Value)
+	}
+	return n
+}
+
+// Front returns the first element of l or nil.
+func (l *List[T]) Front() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+// Back returns the last element of l or nil.
+func (l *List[T]) Back() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// link links e after at.
+func (l *List[T]) link(e, at *Element[T]) {
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+	e.list = l
+	l.len++
+}
+
+// unlink removes e from its list.
+func (l *List[T]) unlink(e *Element[T]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.next = nil
+	e.prev = nil
+	e.list = nil
+	l.len--
+}
+
+func (l *List[T]) insertValue(x T, at *Element[T]) *Element[T] {
+	e := &Element[T]{Value: x}
+	l.link(e, at)
+	return e
+}
+
+// PushFront inserts x at the front of l and returns its element.
+func (l *List[T]) PushFront(x T) *Element[T] {
+	l.lazyInit()
+	return l.insertValue(x, &l.root)
+}
+
+// PushBack inserts x at the back of l and returns its element.
+func (l *List[T]) PushBack(x T) *Element[T] {
+	l.lazyInit()
+	return l.insertValue(x, l.root.prev)
+}
+
+// InsertBefore inserts x immediately before mark and returns its element.
+// If mark is not an element of l, l is not modified and InsertBefore returns nil.
+func (l *List[T]) InsertBefore(x T, mark *Element[T]) *Element[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insertValue(x, mark.prev)
+}
+
+// InsertAfter inserts x immediately after mark and returns its element.
+// If mark is not an element of l, l is not modified and InsertAfter returns nil.
+func (l *List[T]) InsertAfter(x T, mark *Element[T]) *Element[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insertValue(x, mark)
+}
+
+// Remove removes e from l if it is an element of l, and returns e.Value.
+func (l *List[T]) Remove(e *Element[T]) T {
+	if e.list == l {
+		l.unlink(e)
+	}
+	return e.Value
+}
+
+// move moves e, which must be an element of l, to after at.
+func (l *List[T]) move(e, at *Element[T]) {
+	if e == at || e.prev == at {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+}
+
+// MoveToFront moves e to the front of l.
+// If e is not an element of l, l is not modified.
+func (l *List[T]) MoveToFront(e *Element[T]) {
+	if e.list == l {
+		l.move(e, &l.root)
+	}
+}
+
+// MoveToBack moves e to the back of l.
+// If e is not an element of l, l is not modified.
+func (l *List[T]) MoveToBack(e *Element[T]) {
+	if e.list == l {
+		l.move(e, l.root.prev)
+	}
+}
+
+// MoveBefore moves e to immediately before mark.
+// If e or mark is not an element of l, or e == mark, l is not modified.
+func (l *List[T]) MoveBefore(e, mark *Element[T]) {
+	if e.list == l && mark.list == l && e != mark {
+		l.move(e, mark.prev)
+	}
+}
+
+// MoveAfter moves e to immediately after mark.
+// If e or mark is not an element of l, or e == mark, l is not modified.
+func (l *List[T]) MoveAfter(e, mark *Element[T]) {
+	if e.list == l && mark.list == l && e != mark {
+		l.move(e, mark)
+	}
+}
+
+// splice moves all of the elements of other to after at, which must be l.root
+// or an element of l, preserving their order and identity.
+// It takes time proportional to other.Len().
+func (l *List[T]) splice(other *List[T], at *Element[T]) {
+	if other == l || other.len == 0 {
+		return
+	}
+	for e := other.root.next; e != &other.root; e = e.next {
+		e.list = l
+	}
+	first, last := other.root.next, other.root.prev
+	first.prev = at
+	last.next = at.next
+	at.next.prev = last
+	at.next = first
+	l.len += other.len
+
+	other.root.next = &other.root
+	other.root.prev = &other.root
+	other.len = 0
+}
+
+// SpliceFront moves all of the elements of other to the front of l,
+// leaving other empty. The moved elements remain valid.
+func (l *List[T]) SpliceFront(other *List[T]) {
+	l.lazyInit()
+	l.splice(other, &l.root)
+}
+
+// SpliceBack moves all of the elements of other to the back of l,
+// leaving other empty. The moved elements remain valid.
+func (l *List[T]) SpliceBack(other *List[T]) {
+	l.lazyInit()
+	l.splice(other, l.root.prev)
+}
+
+// SpliceBefore moves all of the elements of other to immediately before mark,
+// leaving other empty. If mark is not an element of l, l is not modified.
+func (l *List[T]) SpliceBefore(mark *Element[T], other *List[T]) {
+	if mark.list == l {
+		l.splice(other, mark.prev)
+	}
+}
+
+// SpliceAfter moves all of the elements of other to immediately after mark,
+// leaving other empty. If mark is not an element of l, l is not modified.
+func (l *List[T]) SpliceAfter(mark *Element[T], other *List[T]) {
+	if mark.list == l {
+		l.splice(other, mark)
+	}
+}
+
+// The Range methods visit the elements of l from front to back,
+// keyed by their *Element. The callback may remove the element it is
+// visiting.
+
+func (l *List[T]) RangeKeys(f func(e *Element[T]) bool) {
+	for e := l.Front(); e != nil; {
+		next := e.Next()
+		if !f(e) {
+			break
+		}
+		e = next
+	}
+}
+
+func (l *List[T]) RangeElems(f func(x T) bool) {
+	for e := l.Front(); e != nil; {
+		next := e.Next()
+		if !f(e.Value) {
+			break
+		}
+		e = next
+	}
+}
+
+func (l *List[T]) Range(f func(e *Element[T], x T) bool) {
+	for e := l.Front(); e != nil; {
+		next := e.Next()
+		if !f(e, e.Value) {
+			break
+		}
+		e = next
+	}
+}
+
+// The RangeReverse methods are like the Range methods,
+// but visit the elements of l from back to front.
+
+func (l *List[T]) RangeKeysReverse(f func(e *Element[T]) bool) {
+	for e := l.Back(); e != nil; {
+		prev := e.Prev()
+		if !f(e) {
+			break
+		}
+		e = prev
+	}
+}
+
+func (l *List[T]) RangeElemsReverse(f func(x T) bool) {
+	for e := l.Back(); e != nil; {
+		prev := e.Prev()
+		if !f(e.Value) {
+			break
+		}
+		e = prev
+	}
+}
+
+func (l *List[T]) RangeReverse(f func(e *Element[T], x T) bool) {
+	for e := l.Back(); e != nil; {
+		prev := e.Prev()
+		if !f(e, e.Value) {
+			break
+		}
+		e = prev
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Lenner                                = (*containers.List[int])(nil)
+	_ containers.Ranger[*containers.Element[int], int] = (*containers.List[int])(nil)
+)
+
+// checkList checks that l contains want, in order from front to back and
+// from back to front, with consistent links.
+func checkList(t *testing.T, desc string, l *containers.List[int], want []int) {
+	t.Helper()
+	if l.Len() != len(want) {
+		t.Errorf("%s: Len() = %d; want %d", desc, l.Len(), len(want))
+	}
+	var fwd []int
+	var prev *containers.Element[int]
+	for e := l.Front(); e != nil; e = e.Next() {
+		if e.Prev() != prev {
+			t.Fatalf("%s: element %d: Prev() = %p; want %p", desc, e.Value, e.Prev(), prev)
+		}
+		fwd = append(fwd, e.Value)
+		prev = e
+		if len(fwd) > len(want) {
+			break
+		}
+	}
+	if prev != l.Back() {
+		t.Errorf("%s: Back() = %p; want the last element %p", desc, l.Back(), prev)
+	}
+	var back []int
+	l.RangeElemsReverse(func(x int) bool {
+		back = append(back, x)
+		return len(back) <= len(want)
+	})
+	slices.Reverse(back)
+	if !slices.Equal(fwd, want) || !slices.Equal(back, want) {
+		t.Errorf("%s: contents = %v forward, %v backward; want %v", desc, fwd, back, want)
+	}
+}
+
+func TestList(t *testing.T) {
+	var l containers.List[int]
+	checkList(t, "zero List", &l, nil)
+
+	e2 := l.PushBack(2)
+	e1 := l.PushFront(1)
+	e4 := l.PushBack(4)
+	e3 := l.InsertBefore(3, e4)
+	e5 := l.InsertAfter(5, e4)
+	checkList(t, "after inserts", &l, []int{1, 2, 3, 4, 5})
+
+	l.MoveToFront(e5)
+	checkList(t, "MoveToFront(5)", &l, []int{5, 1, 2, 3, 4})
+	l.MoveToBack(e1)
+	checkList(t, "MoveToBack(1)", &l, []int{5, 2, 3, 4, 1})
+	l.MoveBefore(e1, e2)
+	checkList(t, "MoveBefore(1, 2)", &l, []int{5, 1, 2, 3, 4})
+	l.MoveAfter(e5, e4)
+	checkList(t, "MoveAfter(5, 4)", &l, []int{1, 2, 3, 4, 5})
+	l.MoveAfter(e3, e3)
+	l.MoveBefore(e3, e4)
+	checkList(t, "no-op moves", &l, []int{1, 2, 3, 4, 5})
+
+	if x := l.Remove(e3); x != 3 {
+		t.Errorf("Remove(e3) = %d; want 3", x)
+	}
+	checkList(t, "Remove(3)", &l, []int{1, 2, 4, 5})
+	if e3.Next() != nil || e3.Prev() != nil {
+		t.Errorf("removed element still has neighbors")
+	}
+
+	// Operations with elements that are not in l leave it unchanged.
+	if x := l.Remove(e3); x != 3 {
+		t.Errorf("second Remove(e3) = %d; want 3", x)
+	}
+	if l.InsertBefore(0, e3) != nil || l.InsertAfter(0, e3) != nil {
+		t.Errorf("Insert relative to a removed element returned non-nil")
+	}
+	other := containers.NewList(9)
+	l.MoveToFront(other.Front())
+	l.MoveAfter(e1, other.Front())
+	checkList(t, "after operations on foreign elements", &l, []int{1, 2, 4, 5})
+	checkList(t, "other list", other, []int{9})
+}
+
+func TestListSplice(t *testing.T) {
+	l := containers.NewList(3, 4)
+	front := containers.NewList(1, 2)
+	back := containers.NewList(7, 8)
+	e1 := front.Front()
+	e8 := back.Back()
+
+	l.SpliceFront(front)
+	l.SpliceBack(back)
+	checkList(t, "SpliceFront, SpliceBack", l, []int{1, 2, 3, 4, 7, 8})
+	checkList(t, "spliced-from List", front, nil)
+
+	// Spliced elements keep their identity and now belong to l.
+	if l.Front() != e1 || l.Back() != e8 {
+		t.Errorf("spliced elements were not preserved")
+	}
+	l.MoveToBack(e1)
+	checkList(t, "MoveToBack of a spliced element", l, []int{2, 3, 4, 7, 8, 1})
+	front.PushBack(0)
+	checkList(t, "reused spliced-from List", front, []int{0})
+
+	mid := containers.NewList(5, 6)
+	var e7 *containers.Element[int]
+	l.RangeKeys(func(e *containers.Element[int]) bool {
+		e7 = e
+		return e.Value != 7
+	})
+	l.SpliceBefore(e7, mid)
+	checkList(t, "SpliceBefore(7)", l, []int{2, 3, 4, 5, 6, 7, 8, 1})
+	l.SpliceAfter(e7, containers.NewList(70, 71))
+	checkList(t, "SpliceAfter(7)", l, []int{2, 3, 4, 5, 6, 7, 70, 71, 8, 1})
+
+	// Splicing relative to a foreign mark leaves both lists unchanged.
+	src := containers.NewList(100)
+	l.SpliceAfter(front.Front(), src)
+	checkList(t, "SpliceAfter a foreign mark", l, []int{2, 3, 4, 5, 6, 7, 70, 71, 8, 1})
+	checkList(t, "unspliced List", src, []int{100})
+
+	l.SpliceBack(l)
+	checkList(t, "self-splice", l, []int{2, 3, 4, 5, 6, 7, 70, 71, 8, 1})
+}
+
+func TestListRangeRemove(t *testing.T) {
+	l := containers.NewList(1, 2, 3, 4, 5, 6)
+
+	// The Range callbacks may remove the element they are visiting.
+	l.Range(func(e *containers.Element[int], x int) bool {
+		if x%2 == 0 {
+			l.Remove(e)
+		}
+		return true
+	})
+	checkList(t, "after removing even elements", l, []int{1, 3, 5})
+
+	var visited []int
+	l.RangeReverse(func(e *containers.Element[int], x int) bool {
+		visited = append(visited, x)
+		l.Remove(e)
+		return x != 3
+	})
+	if want := []int{5, 3}; !slices.Equal(visited, want) {
+		t.Errorf("RangeReverse visited %v; want %v", visited, want)
+	}
+	checkList(t, "after RangeReverse", l, []int{1})
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"fmt"
+
+	"github.com/bcmills/go2go/unsafeslice"
+)
+
+// A Matrix is a dense two-dimensional view of a contiguous slice,
+// indexed by [2]int{row, column}.
+//
+// Matrix values are views: copies of a Matrix, and the results of
+// Transpose, Row, and Col, share the same underlying elements.
+type Matrix[T any] struct {
+	data       []T
+	rows, cols int
+	rowStride  int
+	colStride  int
+}
+
+// NewMatrix returns a zeroed Matrix with the given dimensions.
+func NewMatrix[T any](rows, cols int) Matrix[T] {
+	if rows < 0 || cols < 0 {
+		panic(fmt.Sprintf("NewMatrix: negative dimensions %dx%d", rows, cols))
+	}
+	return MatrixOf(rows, cols, make([]T, rows*cols))
+}
+
+// MatrixOf returns a Matrix with the given dimensions
+// backed by data in row-major order.
+func MatrixOf[T any](rows, cols int, data []T) Matrix[T] {
+	if rows < 0 || cols < 0 || rows*cols != len(data) {
+		panic(fmt.Sprintf("MatrixOf: %d elements cannot form a %dx%d matrix", len(data), rows, cols))
+	}
+	return Matrix[T]{data: data, rows: rows, cols: cols, rowStride: cols, colStride: 1}
+}
+
+func (m Matrix[T]) Len() int               { return m.rows * m.cols }
+func (m Matrix[T]) Dims() (rows, cols int) { return m.rows, m.cols }
+func (m Matrix[T]) SizeBytes() int64       { return referencedSize(m) }
+
+func (m Matrix[T]) offset(i, j int) int { return i*m.rowStride + j*m.colStride }
+
+func (m Matrix[T]) Index(ij [2]int) (T, bool) {
+	i, j := ij[0], ij[1]
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return *new(T), false
+	}
+	return m.data[m.offset(i, j)], true
+}
+
+func (m Matrix[T]) SetIndex(ij [2]int, x T) {
+	i, j := ij[0], ij[1]
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		panic(fmt.Sprintf("index %v out of range for %dx%d matrix", ij, m.rows, m.cols))
+	}
+	m.data[m.offset(i, j)] = x
+}
+
+// Row returns a view of row i of m.
+func (m Matrix[T]) Row(i int) Vector[T] {
+	if i < 0 || i >= m.rows {
+		panic(fmt.Sprintf("Row: index %d out of range [0:%d]", i, m.rows))
+	}
+	return Vector[T]{data: m.data, off: m.offset(i, 0), n: m.cols, stride: m.colStride}
+}
+
+// Col returns a view of column j of m.
+func (m Matrix[T]) Col(j int) Vector[T] {
+	if j < 0 || j >= m.cols {
+		panic(fmt.Sprintf("Col: index %d out of range [0:%d]", j, m.cols))
+	}
+	return Vector[T]{data: m.data, off: m.offset(0, j), n: m.rows, stride: m.rowStride}
+}
+
+// Transpose returns a view of m with its rows and columns exchanged.
+func (m Matrix[T]) Transpose() Matrix[T] {
+	return Matrix[T]{
+		data:      m.data,
+		rows:      m.cols,
+		cols:      m.rows,
+		rowStride: m.colStride,
+		colStride: m.rowStride,
+	}
+}
+
+// contiguous reports whether the elements of m are stored in row-major order
+// with no gaps, so that m.data is exactly its elements.
+// A matrix with no elements is trivially contiguous, whatever its strides.
+func (m Matrix[T]) contiguous() bool {
+	if m.rows == 0 || m.cols == 0 {
+		return true
+	}
+	return len(m.data) == m.rows*m.cols && (m.rows <= 1 || m.rowStride == m.cols) && (m.cols <= 1 || m.colStride == 1)
+}
+
+// Reshape returns a view of the elements of m with the given dimensions.
+// It panics if m is not stored contiguously, for example if m is a
+// transposed view, or if rows*cols != m.Len().
+func (m Matrix[T]) Reshape(rows, cols int) Matrix[T] {
+	return ReshapeAs[T](m, rows, cols)
+}
+
+// ReshapeAs returns a Matrix that refers to the same memory as m, but with
+// elements of type T2 and the given dimensions.
+//
+// m must be stored contiguously in row-major order, and the caller must
+// ensure that T2 has a layout compatible with T1 as described in
+// unsafeslice.Convert. For example, a Matrix[complex128] of n columns can be
+// reshaped as a Matrix[float64] of 2n columns.
+func ReshapeAs[T2, T1 any](m Matrix[T1], rows, cols int) Matrix[T2] {
+	if !m.contiguous() {
+		panic("ReshapeAs: matrix is not stored contiguously in row-major order")
+	}
+	n := m.Len()
+	data := unsafeslice.Convert[T1, T2](m.data[:n:n])
+	return MatrixOf(rows, cols, data)
+}
+
+func (m Matrix[T]) RangeKeys(f func(ij [2]int) bool) {
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if !f([2]int{i, j}) {
+				return
+			}
+		}
+	}
+}
+
+func (m Matrix[T]) RangeElems(f func(x T) bool) {
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if !f(m.data[m.offset(i, j)]) {
+				return
+			}
+		}
+	}
+}
+
+func (m Matrix[T]) Range(f func(ij [2]int, x T) bool) {
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			if !f([2]int{i, j}, m.data[m.offset(i, j)]) {
+				return
+			}
+		}
+	}
+}
+
+// A Vector is a strided one-dimensional view of a Matrix,
+// such as a single row or column.
+type Vector[T any] struct {
+	data   []T
+	off    int
+	n      int
+	stride int
+}
+
+func (v Vector[T]) Len() int         { return v.n }
+func (v Vector[T]) SizeBytes() int64 { return referencedSize(v) }
+
+func (v Vector[T]) Index(i int) (T, bool) {
+	if i < 0 || i >= v.n {
+		return *new(T), false
+	}
+	return v.data[v.off+i*v.stride], true
+}
+
+func (v Vector[T]) SetIndex(i int, x T) {
+	if i < 0 || i >= v.n {
+		panic(fmt.Sprintf("index %d out of range [0:%d]", i, v.n))
+	}
+	v.data[v.off+i*v.stride] = x
+}
+
+func (v Vector[T]) RangeKeys(f func(i int) bool) {
+	for i := 0; i < v.n; i++ {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (v Vector[T]) RangeElems(f func(x T) bool) {
+	for i := 0; i < v.n; i++ {
+		if !f(v.data[v.off+i*v.stride]) {
+			break
+		}
+	}
+}
+
+func (v Vector[T]) Range(f func(i int, x T) bool) {
+	for i := 0; i < v.n; i++ {
+		if !f(i, v.data[v.off+i*v.stride]) {
+			break
+		}
+	}
+}
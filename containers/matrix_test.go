@@ -0,0 +1,195 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// mul returns the product a×b, computed as dot products of the Row views of a
+// with the Col views of b.
+func mul(a, b containers.Matrix[int]) containers.Matrix[int] {
+	n, k := a.Dims()
+	_, m := b.Dims()
+	c := containers.NewMatrix[int](n, m)
+	for i := 0; i < n; i++ {
+		row := a.Row(i)
+		for j := 0; j < m; j++ {
+			col := b.Col(j)
+			sum := 0
+			for l := 0; l < k; l++ {
+				x, _ := row.Index(l)
+				y, _ := col.Index(l)
+				sum += x * y
+			}
+			c.SetIndex([2]int{i, j}, sum)
+		}
+	}
+	return c
+}
+
+func elems(m containers.Matrix[int]) []int {
+	var s []int
+	m.RangeElems(func(x int) bool {
+		s = append(s, x)
+		return true
+	})
+	return s
+}
+
+func mustPanic(t *testing.T, desc string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s did not panic", desc)
+		}
+	}()
+	f()
+}
+
+func TestMatrixMul(t *testing.T) {
+	a := containers.MatrixOf(2, 3, []int{
+		1, 2, 3,
+		4, 5, 6,
+	})
+	b := containers.MatrixOf(3, 2, []int{
+		7, 8,
+		9, 10,
+		11, 12,
+	})
+	c := mul(a, b)
+	if r, k := c.Dims(); r != 2 || k != 2 {
+		t.Fatalf("Dims() = %d, %d; want 2, 2", r, k)
+	}
+	if got, want := elems(c), []int{58, 64, 139, 154}; !slices.Equal(got, want) {
+		t.Errorf("a×b = %v; want %v", got, want)
+	}
+
+	// (a×b)ᵀ = bᵀ×aᵀ, computed through transposed views.
+	if got, want := elems(mul(b.Transpose(), a.Transpose())), elems(c.Transpose()); !slices.Equal(got, want) {
+		t.Errorf("bᵀ×aᵀ = %v; want %v", got, want)
+	}
+}
+
+func TestMatrixTranspose(t *testing.T) {
+	m := containers.MatrixOf(2, 3, []int{
+		1, 2, 3,
+		4, 5, 6,
+	})
+	tr := m.Transpose()
+	if r, c := tr.Dims(); r != 3 || c != 2 {
+		t.Fatalf("Transpose().Dims() = %d, %d; want 3, 2", r, c)
+	}
+	if got, want := elems(tr), []int{1, 4, 2, 5, 3, 6}; !slices.Equal(got, want) {
+		t.Errorf("Transpose() = %v; want %v", got, want)
+	}
+
+	// The transpose is a view: writes through it are visible in m.
+	tr.SetIndex([2]int{2, 0}, 30)
+	if x, _ := m.Index([2]int{0, 2}); x != 30 {
+		t.Errorf("after setting Transpose()[2,0], m[0,2] = %d; want 30", x)
+	}
+	if got, want := elems(tr.Transpose()), elems(m); !slices.Equal(got, want) {
+		t.Errorf("Transpose().Transpose() = %v; want %v", got, want)
+	}
+
+	if _, ok := tr.Index([2]int{0, 2}); ok {
+		t.Errorf("Transpose().Index([0 2]) reported ok for a 3x2 matrix")
+	}
+}
+
+func TestMatrixViews(t *testing.T) {
+	m := containers.MatrixOf(3, 2, []int{
+		1, 2,
+		3, 4,
+		5, 6,
+	})
+
+	row := m.Row(1)
+	if row.Len() != 2 {
+		t.Errorf("Row(1).Len() = %d; want 2", row.Len())
+	}
+	col := m.Col(1)
+	if col.Len() != 3 {
+		t.Errorf("Col(1).Len() = %d; want 3", col.Len())
+	}
+	var got []int
+	col.Range(func(i, x int) bool {
+		got = append(got, i, x)
+		return true
+	})
+	if want := []int{0, 2, 1, 4, 2, 6}; !slices.Equal(got, want) {
+		t.Errorf("Col(1).Range: %v; want %v", got, want)
+	}
+
+	// Row and Col views share m's elements.
+	row.SetIndex(1, 40)
+	if x, _ := col.Index(1); x != 40 {
+		t.Errorf("after Row(1).SetIndex(1, 40), Col(1)[1] = %d; want 40", x)
+	}
+	if x, _ := m.Index([2]int{1, 1}); x != 40 {
+		t.Errorf("after Row(1).SetIndex(1, 40), m[1,1] = %d; want 40", x)
+	}
+
+	// A row of the transpose is a column of the original.
+	if got, want := vecElems(m.Transpose().Row(0)), vecElems(m.Col(0)); !slices.Equal(got, want) {
+		t.Errorf("Transpose().Row(0) = %v; want %v", got, want)
+	}
+
+	if _, ok := row.Index(2); ok {
+		t.Errorf("Row(1).Index(2) reported ok for a row of length 2")
+	}
+	mustPanic(t, "Col(2)", func() { m.Col(2) })
+	mustPanic(t, "Row(1).SetIndex(-1, 0)", func() { row.SetIndex(-1, 0) })
+}
+
+func vecElems(v containers.Vector[int]) []int {
+	var s []int
+	v.RangeElems(func(x int) bool {
+		s = append(s, x)
+		return true
+	})
+	return s
+}
+
+func TestMatrixReshape(t *testing.T) {
+	m := containers.MatrixOf(2, 3, []int{
+		1, 2, 3,
+		4, 5, 6,
+	})
+	r := m.Reshape(3, 2)
+	if rows, cols := r.Dims(); rows != 3 || cols != 2 {
+		t.Fatalf("Reshape(3, 2).Dims() = %d, %d; want 3, 2", rows, cols)
+	}
+	if got, want := elems(r), elems(m); !slices.Equal(got, want) {
+		t.Errorf("Reshape(3, 2) = %v; want %v", got, want)
+	}
+	r.SetIndex([2]int{2, 1}, 60)
+	if x, _ := m.Index([2]int{1, 2}); x != 60 {
+		t.Errorf("after setting Reshape(3, 2)[2,1], m[1,2] = %d; want 60", x)
+	}
+
+	mustPanic(t, "Transpose().Reshape", func() { m.Transpose().Reshape(3, 2) })
+	mustPanic(t, "Reshape(4, 2)", func() { m.Reshape(4, 2) })
+
+	// Single rows and columns, and empty matrices, are contiguous
+	// however they are viewed.
+	containers.MatrixOf(1, 3, []int{1, 2, 3}).Transpose().Reshape(1, 3)
+	containers.NewMatrix[int](0, 3).Transpose().Reshape(3, 0)
+
+	c := containers.MatrixOf(1, 2, []complex128{1 + 2i, 3 + 4i})
+	f := containers.ReshapeAs[float64](c, 2, 2)
+	var fs []float64
+	f.RangeElems(func(x float64) bool {
+		fs = append(fs, x)
+		return true
+	})
+	if !slices.Equal(fs, []float64{1, 2, 3, 4}) {
+		t.Errorf("ReshapeAs[float64] = %v; want [1 2 3 4]", fs)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// A Multiset is a set that may contain multiple copies of each element.
+// It maps each distinct element to its (positive) count.
+// The zero Multiset is empty and ready to use.
+type Multiset[T comparable] struct {
+	counts map[T]int
+	total  int
+}
+
+// Add adds n copies of x to m.
+func (m *Multiset[T]) Add(x T, n int) {
+	if n < 0 {
+		panic(fmt.Sprintf("Multiset.Add: negative count %d", n))
+	}
+	if n == 0 {
+		return
+	}
+	if m.counts == nil {
+		m.counts = make(map[T]int)
+	}
+	m.counts[x] += n
+	m.total += n
+}
+
+// Remove removes up to n copies of x from m,
+// and returns the number of copies removed.
+func (m *Multiset[T]) Remove(x T, n int) int {
+	if n < 0 {
+		panic(fmt.Sprintf("Multiset.Remove: negative count %d", n))
+	}
+	c := m.counts[x]
+	if n >= c {
+		n = c
+		delete(m.counts, x)
+	} else {
+		m.counts[x] = c - n
+	}
+	m.total -= n
+	return n
+}
+
+// Count returns the number of copies of x in m.
+func (m *Multiset[T]) Count(x T) int { return m.counts[x] }
+
+// Total returns the number of elements in m, counting each copy.
+func (m *Multiset[T]) Total() int { return m.total }
+
+// Distinct returns the number of distinct elements in m.
+func (m *Multiset[T]) Distinct() int { return len(m.counts) }
+
+// Len returns the number of distinct elements in m, consistent with Map.
+func (m *Multiset[T]) Len() int         { return len(m.counts) }
+func (m *Multiset[T]) SizeBytes() int64 { return referencedSize(m) }
+
+// Index returns the count of x and whether it is nonzero.
+func (m *Multiset[T]) Index(x T) (int, bool) {
+	n, ok := m.counts[x]
+	return n, ok
+}
+
+// MostCommon returns the k distinct elements of m with the highest counts,
+// in decreasing order of count. Ties are broken arbitrarily.
+// If k is negative or greater than m.Distinct(), MostCommon returns all
+// distinct elements.
+//
+// MostCommon takes time O(n log k) for n distinct elements.
+func (m *Multiset[T]) MostCommon(k int) []T {
+	if k < 0 || k > len(m.counts) {
+		k = len(m.counts)
+	}
+	if k == 0 {
+		return []T{}
+	}
+
+	// Keep the k most common elements seen so far in a min-heap by count,
+	// so that the least common of them is at the root.
+	h := make([]T, 0, k)
+	less := func(i, j int) bool { return m.counts[h[i]] < m.counts[h[j]] }
+	for x, n := range m.counts {
+		if len(h) < k {
+			h = append(h, x)
+			for i := len(h) - 1; i > 0; {
+				parent := (i - 1) / 2
+				if !less(i, parent) {
+					break
+				}
+				h[i], h[parent] = h[parent], h[i]
+				i = parent
+			}
+			continue
+		}
+		if n <= m.counts[h[0]] {
+			continue
+		}
+		h[0] = x
+		for i := 0; ; {
+			j := 2*i + 1
+			if j >= k {
+				break
+			}
+			if r := j + 1; r < k && less(r, j) {
+				j = r
+			}
+			if !less(j, i) {
+				break
+			}
+			h[i], h[j] = h[j], h[i]
+			i = j
+		}
+	}
+
+	slices.SortFunc(h, func(a, b T) int { return cmp.Compare(m.counts[b], m.counts[a]) })
+	return h
+}
+
+func (m *Multiset[T]) RangeKeys(f func(x T) bool) {
+	for x := range m.counts {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (m *Multiset[T]) RangeElems(f func(n int) bool) {
+	for _, n := range m.counts {
+		if !f(n) {
+			break
+		}
+	}
+}
+
+func (m *Multiset[T]) Range(f func(x T, n int) bool) {
+	for x, n := range m.counts {
+		if !f(x, n) {
+			break
+		}
+	}
+}
+
+// Union returns a new Multiset in which the count of each element is the
+// maximum of its counts in m and other.
+func (m *Multiset[T]) Union(other *Multiset[T]) *Multiset[T] {
+	u := new(Multiset[T])
+	for x, n := range m.counts {
+		u.Add(x, max(n, other.counts[x]))
+	}
+	for x, n := range other.counts {
+		if _, ok := m.counts[x]; !ok {
+			u.Add(x, n)
+		}
+	}
+	return u
+}
+
+// Intersection returns a new Multiset in which the count of each element is
+// the minimum of its counts in m and other.
+func (m *Multiset[T]) Intersection(other *Multiset[T]) *Multiset[T] {
+	u := new(Multiset[T])
+	for x, n := range m.counts {
+		u.Add(x, min(n, other.counts[x]))
+	}
+	return u
+}
+
+// Sum returns a new Multiset in which the count of each element is the sum
+// of its counts in m and other.
+func (m *Multiset[T]) Sum(other *Multiset[T]) *Multiset[T] {
+	u := new(Multiset[T])
+	for x, n := range m.counts {
+		u.Add(x, n)
+	}
+	for x, n := range other.counts {
+		u.Add(x, n)
+	}
+	return u
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Lenner            = (*containers.Multiset[int])(nil)
+	_ containers.Indexer[int, int] = (*containers.Multiset[int])(nil)
+	_ containers.Ranger[int, int]  = (*containers.Multiset[int])(nil)
+)
+
+func multisetCounts(m *containers.Multiset[string]) map[string]int {
+	counts := make(map[string]int)
+	m.Range(func(x string, n int) bool {
+		counts[x] = n
+		return true
+	})
+	return counts
+}
+
+func TestMultiset(t *testing.T) {
+	var m containers.Multiset[string]
+	m.Add("a", 3)
+	m.Add("b", 1)
+	m.Add("a", 2)
+	m.Add("c", 0)
+	if got, want := multisetCounts(&m), map[string]int{"a": 5, "b": 1}; !maps.Equal(got, want) {
+		t.Errorf("counts = %v; want %v", got, want)
+	}
+	if m.Total() != 6 || m.Distinct() != 2 || m.Len() != 2 {
+		t.Errorf("Total, Distinct, Len = %d, %d, %d; want 6, 2, 2", m.Total(), m.Distinct(), m.Len())
+	}
+	if n, ok := m.Index("c"); ok {
+		t.Errorf(`Index("c") = %d, true; want 0, false`, n)
+	}
+
+	if n := m.Remove("a", 2); n != 2 {
+		t.Errorf(`Remove("a", 2) = %d; want 2`, n)
+	}
+	if n := m.Remove("b", 5); n != 1 {
+		t.Errorf(`Remove("b", 5) = %d; want 1`, n)
+	}
+	if n := m.Remove("z", 1); n != 0 {
+		t.Errorf(`Remove("z", 1) = %d; want 0`, n)
+	}
+	if got, want := multisetCounts(&m), map[string]int{"a": 3}; !maps.Equal(got, want) {
+		t.Errorf("counts after Remove = %v; want %v", got, want)
+	}
+	if m.Total() != 3 || m.Count("b") != 0 {
+		t.Errorf(`Total, Count("b") = %d, %d; want 3, 0`, m.Total(), m.Count("b"))
+	}
+}
+
+func TestMultisetAlgebra(t *testing.T) {
+	var m, other containers.Multiset[string]
+	m.Add("a", 3)
+	m.Add("b", 1)
+	other.Add("a", 1)
+	other.Add("c", 2)
+
+	for _, tc := range []struct {
+		name string
+		got  *containers.Multiset[string]
+		want map[string]int
+	}{
+		{"Union", m.Union(&other), map[string]int{"a": 3, "b": 1, "c": 2}},
+		{"Intersection", m.Intersection(&other), map[string]int{"a": 1}},
+		{"Sum", m.Sum(&other), map[string]int{"a": 4, "b": 1, "c": 2}},
+	} {
+		if got := multisetCounts(tc.got); !maps.Equal(got, tc.want) {
+			t.Errorf("%s = %v; want %v", tc.name, got, tc.want)
+		}
+		total := 0
+		for _, n := range tc.want {
+			total += n
+		}
+		if tc.got.Total() != total {
+			t.Errorf("%s.Total() = %d; want %d", tc.name, tc.got.Total(), total)
+		}
+	}
+}
+
+func TestMultisetMostCommon(t *testing.T) {
+	var m containers.Multiset[int]
+	if got := m.MostCommon(3); len(got) != 0 {
+		t.Errorf("MostCommon(3) of empty Multiset = %v; want []", got)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for x := 0; x < 500; x++ {
+		m.Add(x, 1+r.Intn(50))
+	}
+
+	// The counts of the result must be the k largest counts, in order.
+	var all []int
+	m.RangeElems(func(n int) bool {
+		all = append(all, n)
+		return true
+	})
+	slices.Sort(all)
+	slices.Reverse(all)
+
+	for _, k := range []int{0, 1, 2, 10, 499, 500, 501, -1} {
+		got := m.MostCommon(k)
+		want := all
+		if k >= 0 && k < len(all) {
+			want = all[:k]
+		}
+		counts := make([]int, len(got))
+		for i, x := range got {
+			counts[i] = m.Count(x)
+		}
+		if !slices.Equal(counts, want) {
+			t.Errorf("counts of MostCommon(%d) = %v; want %v", k, counts, want)
+		}
+		seen := make(map[int]bool)
+		for _, x := range got {
+			if seen[x] {
+				t.Errorf("MostCommon(%d) contains %d more than once", k, x)
+			}
+			seen[x] = true
+		}
+	}
+}
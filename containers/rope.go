@@ -0,0 +1,332 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ropeLeafSize is the maximum length of the chunks stored in a Rope.
+const ropeLeafSize = 1024
+
+// A Rope is a sequence of bytes, typically text, stored as a balanced tree of
+// string chunks, so that inserting and deleting within large texts takes time
+// logarithmic in their size.
+//
+// Like String, a Rope is indexed by byte, but ranges over runes.
+// The zero Rope is empty and ready to use.
+//
+// The nodes of a Rope are immutable and shared, so copying a Rope (for
+// example, to keep an undo history) or taking a Slice of one is cheap.
+type Rope struct {
+	root *ropeNode
+}
+
+type ropeNode struct {
+	left, right *ropeNode // both nil for a leaf
+	leaf        string
+	n           int // length in bytes
+	lines       int // number of '\n' bytes
+	height      int
+}
+
+func ropeLeaf(s string) *ropeNode {
+	if s == "" {
+		return nil
+	}
+	return &ropeNode{leaf: s, n: len(s), lines: strings.Count(s, "\n")}
+}
+
+func (n *ropeNode) isLeaf() bool { return n.left == nil }
+
+func ropeHeight(n *ropeNode) int {
+	if n == nil {
+		return -1
+	}
+	return n.height
+}
+
+// ropeConcat returns a node with children l and r, which must be non-nil
+// and within one of each other's heights.
+func ropeConcat(l, r *ropeNode) *ropeNode {
+	if l.isLeaf() && r.isLeaf() && l.n+r.n <= ropeLeafSize {
+		return ropeLeaf(l.leaf + r.leaf)
+	}
+	return &ropeNode{
+		left:   l,
+		right:  r,
+		n:      l.n + r.n,
+		lines:  l.lines + r.lines,
+		height: 1 + max(l.height, r.height),
+	}
+}
+
+// ropeBalance returns a node equivalent to ropeConcat(l, r),
+// rotating as needed to restore balance if one side is two levels taller.
+func ropeBalance(l, r *ropeNode) *ropeNode {
+	switch hl, hr := ropeHeight(l), ropeHeight(r); {
+	case hl > hr+1:
+		if ropeHeight(l.left) >= ropeHeight(l.right) {
+			return ropeConcat(l.left, ropeConcat(l.right, r))
+		}
+		return ropeConcat(ropeConcat(l.left, l.right.left), ropeConcat(l.right.right, r))
+	case hr > hl+1:
+		if ropeHeight(r.right) >= ropeHeight(r.left) {
+			return ropeConcat(ropeConcat(l, r.left), r.right)
+		}
+		return ropeConcat(ropeConcat(l, r.left.left), ropeConcat(r.left.right, r.right))
+	default:
+		return ropeConcat(l, r)
+	}
+}
+
+// ropeJoin returns the concatenation of l and r, either of which may be nil,
+// as a balanced tree.
+func ropeJoin(l, r *ropeNode) *ropeNode {
+	switch {
+	case l == nil:
+		return r
+	case r == nil:
+		return l
+	case l.height > r.height+1:
+		return ropeBalance(l.left, ropeJoin(l.right, r))
+	case r.height > l.height+1:
+		return ropeBalance(ropeJoin(l, r.left), r.right)
+	default:
+		return ropeConcat(l, r)
+	}
+}
+
+// ropeSplit returns the first i bytes of n and the remainder.
+func ropeSplit(n *ropeNode, i int) (*ropeNode, *ropeNode) {
+	switch {
+	case n == nil:
+		return nil, nil
+	case i <= 0:
+		return nil, n
+	case i >= n.n:
+		return n, nil
+	case n.isLeaf():
+		return ropeLeaf(n.leaf[:i]), ropeLeaf(n.leaf[i:])
+	case i < n.left.n:
+		ll, lr := ropeSplit(n.left, i)
+		return ll, ropeJoin(lr, n.right)
+	default:
+		rl, rr := ropeSplit(n.right, i-n.left.n)
+		return ropeJoin(n.left, rl), rr
+	}
+}
+
+// ropeBuild returns a balanced tree of leaves containing s.
+func ropeBuild(s string) *ropeNode {
+	if len(s) <= ropeLeafSize {
+		return ropeLeaf(s)
+	}
+	leaves := (len(s) + ropeLeafSize - 1) / ropeLeafSize
+	mid := (leaves / 2) * ropeLeafSize
+	return ropeConcat(ropeBuild(s[:mid]), ropeBuild(s[mid:]))
+}
+
+// RopeOf returns a Rope containing s.
+func RopeOf(s string) Rope { return Rope{root: ropeBuild(s)} }
+
+func (r Rope) Len() int {
+	if r.root == nil {
+		return 0
+	}
+	return r.root.n
+}
+
+func (r Rope) SizeBytes() int64 { return referencedSize(r) }
+
+// String returns the contents of r as a string.
+func (r Rope) String() string {
+	var b strings.Builder
+	b.Grow(r.Len())
+	r.walkLeaves(func(_ int, s string) bool {
+		b.WriteString(s)
+		return true
+	})
+	return b.String()
+}
+
+func (r Rope) checkRange(op string, lo, hi int) {
+	if lo < 0 || hi > r.Len() || lo > hi {
+		panic(fmt.Sprintf("%s: range [%d:%d] out of bounds [0:%d]", op, lo, hi, r.Len()))
+	}
+}
+
+// Insert inserts s at byte offset i.
+func (r *Rope) Insert(i int, s string) {
+	r.checkRange("Rope.Insert", i, i)
+	l, rest := ropeSplit(r.root, i)
+	r.root = ropeJoin(ropeJoin(l, ropeBuild(s)), rest)
+}
+
+// Delete removes the bytes in [lo, hi).
+func (r *Rope) Delete(lo, hi int) {
+	r.checkRange("Rope.Delete", lo, hi)
+	head, tail := ropeSplit(r.root, hi)
+	l, _ := ropeSplit(head, lo)
+	r.root = ropeJoin(l, tail)
+}
+
+// Slice returns the bytes of r in [lo, hi) as a new Rope.
+func (r Rope) Slice(lo, hi int) Rope {
+	r.checkRange("Rope.Slice", lo, hi)
+	head, _ := ropeSplit(r.root, hi)
+	_, s := ropeSplit(head, lo)
+	return Rope{root: s}
+}
+
+// Append returns the concatenation of r and other.
+func (r Rope) Append(other Rope) Rope {
+	return Rope{root: ropeJoin(r.root, other.root)}
+}
+
+func (r Rope) Index(i int) (byte, bool) {
+	if i < 0 || i >= r.Len() {
+		return 0, false
+	}
+	n := r.root
+	for !n.isLeaf() {
+		if i < n.left.n {
+			n = n.left
+		} else {
+			i -= n.left.n
+			n = n.right
+		}
+	}
+	return n.leaf[i], true
+}
+
+// walkLeaves calls f with the offset and contents of each leaf in order,
+// stopping early if f returns false.
+func (r Rope) walkLeaves(f func(off int, s string) bool) {
+	var walk func(n *ropeNode, off int) bool
+	walk = func(n *ropeNode, off int) bool {
+		if n == nil {
+			return true
+		}
+		if n.isLeaf() {
+			return f(off, n.leaf)
+		}
+		return walk(n.left, off) && walk(n.right, off+n.left.n)
+	}
+	walk(r.root, 0)
+}
+
+// decodeRune decodes the rune starting at byte offset i,
+// which may span multiple leaves.
+func (r Rope) decodeRune(i int) (rune, int) {
+	var buf [utf8.UTFMax]byte
+	n := 0
+	for ; n < len(buf) && i+n < r.Len(); n++ {
+		buf[n], _ = r.Index(i + n)
+	}
+	return utf8.DecodeRune(buf[:n])
+}
+
+func (r Rope) Range(f func(i int, c rune) bool) {
+	skip := 0 // bytes at the start of the next leaf belonging to a previous rune
+	r.walkLeaves(func(off int, s string) bool {
+		i := skip
+		for i < len(s) {
+			var c rune
+			var size int
+			if utf8.FullRuneInString(s[i:]) {
+				c, size = utf8.DecodeRuneInString(s[i:])
+			} else {
+				c, size = r.decodeRune(off + i)
+			}
+			if !f(off+i, c) {
+				return false
+			}
+			i += size
+		}
+		skip = i - len(s)
+		return true
+	})
+}
+
+func (r Rope) RangeKeys(f func(i int) bool) {
+	r.Range(func(i int, _ rune) bool { return f(i) })
+}
+
+func (r Rope) RangeElems(f func(c rune) bool) {
+	r.Range(func(_ int, c rune) bool { return f(c) })
+}
+
+// Lines returns the number of lines in r: one more than the number of
+// newline characters.
+func (r Rope) Lines() int {
+	if r.root == nil {
+		return 1
+	}
+	return r.root.lines + 1
+}
+
+// LineCol returns the zero-based line number containing byte offset i,
+// and the byte offset of i from the start of that line.
+func (r Rope) LineCol(i int) (line, col int) {
+	r.checkRange("Rope.LineCol", i, i)
+	line = r.linesBefore(i)
+	return line, i - r.lineStart(line)
+}
+
+// Offset returns the byte offset of the given zero-based line and byte
+// column. It panics if line is out of range.
+func (r Rope) Offset(line, col int) int {
+	if line < 0 || line >= r.Lines() {
+		panic(fmt.Sprintf("Rope.Offset: line %d out of range [0:%d]", line, r.Lines()))
+	}
+	return r.lineStart(line) + col
+}
+
+// linesBefore returns the number of newlines in the first i bytes.
+func (r Rope) linesBefore(i int) int {
+	lines := 0
+	for n := r.root; n != nil && i > 0; {
+		if n.isLeaf() {
+			return lines + strings.Count(n.leaf[:i], "\n")
+		}
+		if i < n.left.n {
+			n = n.left
+		} else {
+			lines += n.left.lines
+			i -= n.left.n
+			n = n.right
+		}
+	}
+	return lines
+}
+
+// lineStart returns the byte offset of the start of the given line:
+// the offset just past the line'th newline.
+func (r Rope) lineStart(line int) int {
+	if line == 0 {
+		return 0
+	}
+	off := 0
+	n := r.root
+	for !n.isLeaf() {
+		if line <= n.left.lines {
+			n = n.left
+		} else {
+			line -= n.left.lines
+			off += n.left.n
+			n = n.right
+		}
+	}
+	s := n.leaf
+	for ; line > 0; line-- {
+		j := strings.IndexByte(s, '\n') + 1
+		off += j
+		s = s[j:]
+	}
+	return off
+}
@@ -0,0 +1,177 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Lenner             = containers.Rope{}
+	_ containers.Indexer[int, byte] = containers.Rope{}
+	_ containers.Ranger[int, rune]  = containers.Rope{}
+)
+
+// ropeLeafSize is the chunk size of a Rope; see rope.go.
+const ropeLeafSize = 1024
+
+var ropeAlphabet = []string{"a", "b", "\n", "é", "世", "😀"}
+
+// randomText returns a string of about n bytes, mixing ASCII, newlines,
+// and runes of every encoded length.
+func randomText(r *rand.Rand, n int) string {
+	var b strings.Builder
+	for b.Len() < n {
+		b.WriteString(ropeAlphabet[r.Intn(len(ropeAlphabet))])
+	}
+	return b.String()
+}
+
+// straddlingText returns a string in which a multi-byte rune crosses each
+// multiple of ropeLeafSize, up to n bytes.
+func straddlingText(n int) string {
+	var b strings.Builder
+	for i := 0; b.Len() < n; i++ {
+		r := ropeAlphabet[3+i%3]
+		b.WriteString(strings.Repeat("x", ropeLeafSize-b.Len()%ropeLeafSize-1))
+		b.WriteString(r)
+		if i%4 == 0 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func checkRope(t *testing.T, r *rand.Rand, desc string, rope containers.Rope, want string) {
+	t.Helper()
+	if got := rope.String(); got != want {
+		t.Fatalf("%s: String() differs from want (lengths %d, %d)", desc, len(got), len(want))
+	}
+	if rope.Len() != len(want) {
+		t.Fatalf("%s: Len() = %d; want %d", desc, rope.Len(), len(want))
+	}
+
+	type runeAt struct {
+		i int
+		c rune
+	}
+	var wantRunes []runeAt
+	for i, c := range want {
+		wantRunes = append(wantRunes, runeAt{i, c})
+	}
+	k := 0
+	rope.Range(func(i int, c rune) bool {
+		if k >= len(wantRunes) || wantRunes[k] != (runeAt{i, c}) {
+			t.Fatalf("%s: Range yielded (%d, %q) at step %d; want %v", desc, i, c, k, wantRunes[k:min(k+1, len(wantRunes))])
+		}
+		k++
+		return true
+	})
+	if k != len(wantRunes) {
+		t.Fatalf("%s: Range yielded %d runes; want %d", desc, k, len(wantRunes))
+	}
+
+	if got, want := rope.Lines(), strings.Count(want, "\n")+1; got != want {
+		t.Fatalf("%s: Lines() = %d; want %d", desc, got, want)
+	}
+
+	for j := 0; j < 20; j++ {
+		i := r.Intn(len(want) + 1)
+		if i < len(want) {
+			if b, ok := rope.Index(i); !ok || b != want[i] {
+				t.Fatalf("%s: Index(%d) = %q, %v; want %q, true", desc, i, b, ok, want[i])
+			}
+		}
+		wantLine := strings.Count(want[:i], "\n")
+		wantCol := i - (strings.LastIndexByte(want[:i], '\n') + 1)
+		if line, col := rope.LineCol(i); line != wantLine || col != wantCol {
+			t.Fatalf("%s: LineCol(%d) = %d, %d; want %d, %d", desc, i, line, col, wantLine, wantCol)
+		}
+		if off := rope.Offset(wantLine, wantCol); off != i {
+			t.Fatalf("%s: Offset(%d, %d) = %d; want %d", desc, wantLine, wantCol, off, i)
+		}
+	}
+	if _, ok := rope.Index(len(want)); ok {
+		t.Fatalf("%s: Index(Len()) reported ok", desc)
+	}
+}
+
+func TestRope(t *testing.T) {
+	var empty containers.Rope
+	if empty.Len() != 0 || empty.String() != "" || empty.Lines() != 1 {
+		t.Errorf("zero Rope: Len, String, Lines = %d, %q, %d; want 0, \"\", 1", empty.Len(), empty.String(), empty.Lines())
+	}
+
+	rope := containers.RopeOf("hello\nworld")
+	rope.Insert(5, ", 世界")
+	rope.Delete(0, 1)
+	if got, want := rope.String(), "ello, 世界\nworld"; got != want {
+		t.Errorf("String() = %q; want %q", got, want)
+	}
+	if got, want := rope.Slice(6, 12).String(), "世界"; got != want {
+		t.Errorf("Slice(6, 12) = %q; want %q", got, want)
+	}
+	if line, col := rope.LineCol(rope.Len()); line != 1 || col != 5 {
+		t.Errorf("LineCol(Len()) = %d, %d; want 1, 5", line, col)
+	}
+}
+
+func TestRopeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	want := straddlingText(5 * ropeLeafSize)
+	rope := containers.RopeOf(want)
+	checkRope(t, r, "RopeOf", rope, want)
+
+	// offset returns a random offset in want, usually on a rune boundary
+	// but sometimes within a rune.
+	offset := func() int {
+		i := r.Intn(len(want) + 1)
+		for r.Intn(8) != 0 && i < len(want) && want[i]&0xC0 == 0x80 {
+			i++
+		}
+		return i
+	}
+
+	for step := 0; step < 300; step++ {
+		var desc string
+		switch op := r.Intn(4); {
+		case op == 0 || len(want) < ropeLeafSize:
+			i := offset()
+			s := randomText(r, r.Intn(3*ropeLeafSize))
+			desc = "Insert"
+			rope.Insert(i, s)
+			want = want[:i] + s + want[i:]
+		case op == 1:
+			lo := offset()
+			hi := min(len(want), lo+r.Intn(2*ropeLeafSize))
+			desc = "Delete"
+			rope.Delete(lo, hi)
+			want = want[:lo] + want[hi:]
+		case op == 2:
+			lo, hi := offset(), offset()
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			desc = "Slice"
+			checkRope(t, r, desc, rope.Slice(lo, hi), want[lo:hi])
+		default:
+			s := straddlingText(r.Intn(2 * ropeLeafSize))
+			desc = "Append"
+			old := rope
+			rope = rope.Append(containers.RopeOf(s))
+			checkRope(t, r, "Append (original)", old, want)
+			want += s
+		}
+		if len(want) > 16*ropeLeafSize {
+			rope.Delete(0, len(want)/2)
+			want = want[len(want)/2:]
+		}
+		checkRope(t, r, desc, rope, want)
+	}
+}
@@ -0,0 +1,391 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"cmp"
+	"container/heap"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	rtreeMaxEntries = 16
+	rtreeMinEntries = rtreeMaxEntries * 2 / 5
+)
+
+// An RTree is a spatial index of values with N-dimensional rectangular
+// bounds, using Guttman's R-tree with quadratic splits.
+// For static data, Load builds a packed tree using Sort-Tile-Recursive.
+type RTree[T any] struct {
+	dims int
+	root *rnode[T]
+	n    int
+}
+
+type rnode[T any] struct {
+	leaf    bool
+	entries []rentry[T]
+}
+
+// An rentry is either a child node (in an interior node)
+// or a value (in a leaf).
+type rentry[T any] struct {
+	rect  Rect
+	child *rnode[T]
+	v     T
+}
+
+// NewRTree returns an empty RTree with the given number of dimensions.
+func NewRTree[T any](dims int) *RTree[T] {
+	if dims <= 0 {
+		panic(fmt.Sprintf("NewRTree: invalid dimension %d", dims))
+	}
+	return &RTree[T]{dims: dims, root: &rnode[T]{leaf: true}}
+}
+
+func (t *RTree[T]) Len() int         { return t.n }
+func (t *RTree[T]) SizeBytes() int64 { return referencedSize(t) }
+
+func (n *rnode[T]) bounds() Rect {
+	r := n.entries[0].rect
+	for _, e := range n.entries[1:] {
+		r = r.Union(e.rect)
+	}
+	return r
+}
+
+// Load replaces the contents of t with a packed tree containing
+// values[i] with bounds rects[i] for each i.
+func (t *RTree[T]) Load(rects []Rect, values []T) {
+	if len(rects) != len(values) {
+		panic(fmt.Sprintf("RTree.Load: %d rects for %d values", len(rects), len(values)))
+	}
+	entries := make([]rentry[T], len(rects))
+	for i, r := range rects {
+		checkRectDims("RTree.Load", t.dims, r)
+		entries[i] = rentry[T]{rect: r, v: values[i]}
+	}
+	t.n = len(entries)
+	if len(entries) == 0 {
+		t.root = &rnode[T]{leaf: true}
+		return
+	}
+	leaf := true
+	for {
+		nodes := t.strPack(entries, 0, leaf)
+		if len(nodes) == 1 {
+			t.root = nodes[0]
+			return
+		}
+		entries = make([]rentry[T], len(nodes))
+		for i, n := range nodes {
+			entries[i] = rentry[T]{rect: n.bounds(), child: n}
+		}
+		leaf = false
+	}
+}
+
+// strPack packs entries into nodes by sorting them into slabs along each
+// axis in turn, starting at axis.
+func (t *RTree[T]) strPack(entries []rentry[T], axis int, leaf bool) []*rnode[T] {
+	pages := (len(entries) + rtreeMaxEntries - 1) / rtreeMaxEntries
+	if axis == t.dims-1 || pages <= 1 {
+		slices.SortFunc(entries, func(a, b rentry[T]) int {
+			return cmp.Compare(a.rect.center(axis), b.rect.center(axis))
+		})
+		var nodes []*rnode[T]
+		for len(entries) > 0 {
+			n := min(len(entries), rtreeMaxEntries)
+			nodes = append(nodes, &rnode[T]{leaf: leaf, entries: slices.Clone(entries[:n])})
+			entries = entries[n:]
+		}
+		return nodes
+	}
+
+	slabs := int(math.Ceil(math.Pow(float64(pages), 1/float64(t.dims-axis))))
+	slabSize := rtreeMaxEntries * int(math.Ceil(float64(pages)/float64(slabs)))
+	slices.SortFunc(entries, func(a, b rentry[T]) int {
+		return cmp.Compare(a.rect.center(axis), b.rect.center(axis))
+	})
+	var nodes []*rnode[T]
+	for len(entries) > 0 {
+		n := min(len(entries), slabSize)
+		nodes = append(nodes, t.strPack(entries[:n], axis+1, leaf)...)
+		entries = entries[n:]
+	}
+	return nodes
+}
+
+// Insert adds v with bounds r.
+func (t *RTree[T]) Insert(r Rect, v T) {
+	checkRectDims("RTree.Insert", t.dims, r)
+	t.insert(rentry[T]{rect: r, v: v})
+	t.n++
+}
+
+// insert adds the value entry e to a leaf, growing the tree if the root splits.
+func (t *RTree[T]) insert(e rentry[T]) {
+	if split := t.insertAt(t.root, e); split != nil {
+		old := t.root
+		t.root = &rnode[T]{entries: []rentry[T]{
+			{rect: old.bounds(), child: old},
+			{rect: split.bounds(), child: split},
+		}}
+	}
+}
+
+// insertAt adds the value entry e to a leaf in the subtree n.
+// If n overflows, insertAt splits it and returns the new sibling.
+func (t *RTree[T]) insertAt(n *rnode[T], e rentry[T]) *rnode[T] {
+	if n.leaf {
+		n.entries = append(n.entries, e)
+	} else {
+		i := chooseSubtree(n, e.rect)
+		c := &n.entries[i]
+		split := t.insertAt(c.child, e)
+		c.rect = c.child.bounds()
+		if split != nil {
+			n.entries = append(n.entries, rentry[T]{rect: split.bounds(), child: split})
+		}
+	}
+	if len(n.entries) > rtreeMaxEntries {
+		return quadraticSplit(n)
+	}
+	return nil
+}
+
+// chooseSubtree returns the index of the entry of n whose rectangle needs
+// the least enlargement to include r.
+func chooseSubtree[T any](n *rnode[T], r Rect) int {
+	best := 0
+	bestGrowth, bestVolume := math.Inf(1), math.Inf(1)
+	for i, e := range n.entries {
+		v := e.rect.Volume()
+		growth := e.rect.Union(r).Volume() - v
+		if growth < bestGrowth || (growth == bestGrowth && v < bestVolume) {
+			best, bestGrowth, bestVolume = i, growth, v
+		}
+	}
+	return best
+}
+
+// quadraticSplit splits the entries of n between n and a new sibling,
+// which it returns.
+func quadraticSplit[T any](n *rnode[T]) *rnode[T] {
+	entries := n.entries
+
+	// Pick the two seeds that would waste the most volume together.
+	s1, s2 := 0, 1
+	worst := math.Inf(-1)
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			d := entries[i].rect.Union(entries[j].rect).Volume() - entries[i].rect.Volume() - entries[j].rect.Volume()
+			if d > worst {
+				s1, s2, worst = i, j, d
+			}
+		}
+	}
+
+	a := &rnode[T]{leaf: n.leaf, entries: []rentry[T]{entries[s1]}}
+	b := &rnode[T]{leaf: n.leaf, entries: []rentry[T]{entries[s2]}}
+	ra, rb := entries[s1].rect, entries[s2].rect
+	rest := make([]rentry[T], 0, len(entries)-2)
+	for i, e := range entries {
+		if i != s1 && i != s2 {
+			rest = append(rest, e)
+		}
+	}
+
+	for len(rest) > 0 {
+		// If one group needs all the rest to reach the minimum, give them to it.
+		if len(a.entries)+len(rest) == rtreeMinEntries {
+			a.entries = append(a.entries, rest...)
+			break
+		}
+		if len(b.entries)+len(rest) == rtreeMinEntries {
+			b.entries = append(b.entries, rest...)
+			break
+		}
+
+		// Assign the entry with the greatest preference for one group.
+		next, maxDiff := 0, math.Inf(-1)
+		var growA, growB float64
+		for i, e := range rest {
+			ga := ra.Union(e.rect).Volume() - ra.Volume()
+			gb := rb.Union(e.rect).Volume() - rb.Volume()
+			if d := math.Abs(ga - gb); d > maxDiff {
+				next, maxDiff, growA, growB = i, d, ga, gb
+			}
+		}
+		e := rest[next]
+		rest = slices.Delete(rest, next, next+1)
+		if growA < growB || (growA == growB && len(a.entries) <= len(b.entries)) {
+			a.entries = append(a.entries, e)
+			ra = ra.Union(e.rect)
+		} else {
+			b.entries = append(b.entries, e)
+			rb = rb.Union(e.rect)
+		}
+	}
+
+	n.entries = a.entries
+	return b
+}
+
+// Delete removes one value with bounds exactly r for which match reports
+// true, or any value with bounds r if match is nil.
+// It reports whether a value was removed.
+func (t *RTree[T]) Delete(r Rect, match func(T) bool) bool {
+	checkRectDims("RTree.Delete", t.dims, r)
+	var orphans []rentry[T]
+	if !t.delete(t.root, r, match, &orphans) {
+		return false
+	}
+	t.n--
+
+	// Shorten the tree while the root has only one child.
+	for !t.root.leaf && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	if !t.root.leaf && len(t.root.entries) == 0 {
+		t.root = &rnode[T]{leaf: true}
+	}
+
+	// Reinsert the values from underfull nodes that were removed.
+	for _, e := range orphans {
+		t.insert(e)
+	}
+	return true
+}
+
+// collect appends the value entries in the subtree n to dst.
+func (n *rnode[T]) collect(dst *[]rentry[T]) {
+	if n.leaf {
+		*dst = append(*dst, n.entries...)
+		return
+	}
+	for _, e := range n.entries {
+		e.child.collect(dst)
+	}
+}
+
+// delete removes a matching value from the subtree n. Underfull nodes are
+// removed from the tree, and their values appended to orphans.
+func (t *RTree[T]) delete(n *rnode[T], r Rect, match func(T) bool, orphans *[]rentry[T]) bool {
+	if n.leaf {
+		for i, e := range n.entries {
+			if e.rect.equal(r) && (match == nil || match(e.v)) {
+				n.entries = slices.Delete(n.entries, i, i+1)
+				return true
+			}
+		}
+		return false
+	}
+	for i := range n.entries {
+		c := &n.entries[i]
+		if !c.rect.Contains(r) || !t.delete(c.child, r, match, orphans) {
+			continue
+		}
+		if len(c.child.entries) < rtreeMinEntries {
+			c.child.collect(orphans)
+			n.entries = slices.Delete(n.entries, i, i+1)
+		} else {
+			c.rect = c.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+// walk calls f for each value in the subtree n whose bounds satisfy
+// overlaps, pruning subtrees whose bounds do not.
+func (n *rnode[T]) walk(overlaps func(Rect) bool, f func(T) bool) bool {
+	for _, e := range n.entries {
+		if !overlaps(e.rect) {
+			continue
+		}
+		if n.leaf {
+			if !f(e.v) {
+				return false
+			}
+		} else if !e.child.walk(overlaps, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *RTree[T]) RangeElems(f func(T) bool) {
+	t.root.walk(func(Rect) bool { return true }, f)
+}
+
+// Intersecting returns the values whose bounds intersect rect.
+// The query is evaluated lazily each time the result is ranged over.
+func (t *RTree[T]) Intersecting(rect Rect) ElemRanger[T] {
+	checkRectDims("RTree.Intersecting", t.dims, rect)
+	return spatialQuery[T](func(f func(T) bool) {
+		t.root.walk(rect.Intersects, f)
+	})
+}
+
+// WithinRadius returns the values whose bounds come within distance r of p.
+// The query is evaluated lazily each time the result is ranged over.
+func (t *RTree[T]) WithinRadius(p Point, r float64) ElemRanger[T] {
+	checkDims("RTree.WithinRadius", t.dims, p)
+	r2 := r * r
+	return spatialQuery[T](func(f func(T) bool) {
+		t.root.walk(func(b Rect) bool { return b.dist2(p) <= r2 }, f)
+	})
+}
+
+// Nearest returns the (up to) k values whose bounds are nearest to p,
+// in increasing order of distance.
+func (t *RTree[T]) Nearest(p Point, k int) Slice[T] {
+	checkDims("RTree.Nearest", t.dims, p)
+	var result Slice[T]
+	if k <= 0 {
+		return result
+	}
+
+	// Best-first search: entries are popped in order of distance,
+	// so values are produced in order as well.
+	q := &rqueue[T]{}
+	for _, e := range t.root.entries {
+		heap.Push(q, rqueueItem[T]{d: e.rect.dist2(p), e: e, leaf: t.root.leaf})
+	}
+	for q.Len() > 0 && len(result) < k {
+		it := heap.Pop(q).(rqueueItem[T])
+		if it.leaf {
+			result = append(result, it.e.v)
+			continue
+		}
+		for _, e := range it.e.child.entries {
+			heap.Push(q, rqueueItem[T]{d: e.rect.dist2(p), e: e, leaf: it.e.child.leaf})
+		}
+	}
+	return result
+}
+
+type rqueueItem[T any] struct {
+	d    float64
+	e    rentry[T]
+	leaf bool // e is a value rather than a child node
+}
+
+type rqueue[T any] []rqueueItem[T]
+
+func (q rqueue[T]) Len() int           { return len(q) }
+func (q rqueue[T]) Less(i, j int) bool { return q[i].d < q[j].d }
+func (q rqueue[T]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *rqueue[T]) Push(x any)        { *q = append(*q, x.(rqueueItem[T])) }
+
+func (q *rqueue[T]) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
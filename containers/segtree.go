@@ -0,0 +1,189 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import "fmt"
+
+// A SegmentTree is a sequence of values supporting queries of the aggregate
+// of any contiguous range, and assignment to any contiguous range,
+// in logarithmic time.
+//
+// The aggregate is computed with a user-supplied combine function,
+// which must be associative and have the given identity:
+// for example, addition with 0, min with +Inf, or max with -Inf.
+//
+// Methods that only read a SegmentTree, such as Query and Index,
+// are safe to call concurrently with each other.
+type SegmentTree[T any] struct {
+	n        int
+	combine  func(T, T) T
+	identity T
+	agg      []T    // aggregate of each node's segment, as a heap-ordered binary tree
+	pending  []T    // lazily-assigned value for each node's segment
+	assigned []bool // whether pending is set
+}
+
+// NewSegmentTree returns a SegmentTree containing a copy of xs.
+func NewSegmentTree[T any](xs []T, combine func(T, T) T, identity T) *SegmentTree[T] {
+	t := &SegmentTree[T]{
+		n:        len(xs),
+		combine:  combine,
+		identity: identity,
+	}
+	if t.n > 0 {
+		t.agg = make([]T, 4*t.n)
+		t.pending = make([]T, 4*t.n)
+		t.assigned = make([]bool, 4*t.n)
+		t.build(1, 0, t.n, xs)
+	}
+	return t
+}
+
+func (t *SegmentTree[T]) build(node, lo, hi int, xs []T) {
+	if hi-lo == 1 {
+		t.agg[node] = xs[lo]
+		return
+	}
+	mid := (lo + hi) / 2
+	t.build(2*node, lo, mid, xs)
+	t.build(2*node+1, mid, hi, xs)
+	t.agg[node] = t.combine(t.agg[2*node], t.agg[2*node+1])
+}
+
+// repeat returns the aggregate of n copies of x, using O(log n) combines.
+func (t *SegmentTree[T]) repeat(x T, n int) T {
+	result := t.identity
+	for n > 0 {
+		if n&1 != 0 {
+			result = t.combine(result, x)
+		}
+		x = t.combine(x, x)
+		n >>= 1
+	}
+	return result
+}
+
+// assign sets every element in the segment of node, which has length n, to x.
+func (t *SegmentTree[T]) assign(node, n int, x T) {
+	t.agg[node] = t.repeat(x, n)
+	t.pending[node] = x
+	t.assigned[node] = true
+}
+
+// push propagates a pending assignment at node to its children.
+func (t *SegmentTree[T]) push(node, lo, mid, hi int) {
+	if !t.assigned[node] {
+		return
+	}
+	x := t.pending[node]
+	t.assign(2*node, mid-lo, x)
+	t.assign(2*node+1, hi-mid, x)
+	t.pending[node] = t.identity
+	t.assigned[node] = false
+}
+
+func (t *SegmentTree[T]) Len() int         { return t.n }
+func (t *SegmentTree[T]) SizeBytes() int64 { return referencedSize(t) }
+
+func (t *SegmentTree[T]) checkRange(op string, lo, hi int) {
+	if lo < 0 || hi > t.n || lo > hi {
+		panic(fmt.Sprintf("%s: range [%d:%d] out of bounds [0:%d]", op, lo, hi, t.n))
+	}
+}
+
+// Query returns the aggregate of the elements in [lo, hi),
+// or the identity if the range is empty.
+func (t *SegmentTree[T]) Query(lo, hi int) T {
+	t.checkRange("SegmentTree.Query", lo, hi)
+	if lo == hi {
+		return t.identity
+	}
+	return t.query(1, 0, t.n, lo, hi)
+}
+
+func (t *SegmentTree[T]) query(node, nlo, nhi, lo, hi int) T {
+	if lo <= nlo && nhi <= hi {
+		return t.agg[node]
+	}
+	if t.assigned[node] {
+		// Every element in the segment equals the pending value. Use it
+		// directly instead of pushing it down, so that queries do not modify
+		// t and can run concurrently.
+		return t.repeat(t.pending[node], min(hi, nhi)-max(lo, nlo))
+	}
+	mid := (nlo + nhi) / 2
+	switch {
+	case hi <= mid:
+		return t.query(2*node, nlo, mid, lo, hi)
+	case lo >= mid:
+		return t.query(2*node+1, mid, nhi, lo, hi)
+	default:
+		return t.combine(t.query(2*node, nlo, mid, lo, hi), t.query(2*node+1, mid, nhi, lo, hi))
+	}
+}
+
+// SetRange sets every element in [lo, hi) to x.
+func (t *SegmentTree[T]) SetRange(lo, hi int, x T) {
+	t.checkRange("SegmentTree.SetRange", lo, hi)
+	if lo < hi {
+		t.setRange(1, 0, t.n, lo, hi, x)
+	}
+}
+
+func (t *SegmentTree[T]) setRange(node, nlo, nhi, lo, hi int, x T) {
+	if lo <= nlo && nhi <= hi {
+		t.assign(node, nhi-nlo, x)
+		return
+	}
+	mid := (nlo + nhi) / 2
+	t.push(node, nlo, mid, nhi)
+	if lo < mid {
+		t.setRange(2*node, nlo, mid, lo, hi, x)
+	}
+	if hi > mid {
+		t.setRange(2*node+1, mid, nhi, lo, hi, x)
+	}
+	t.agg[node] = t.combine(t.agg[2*node], t.agg[2*node+1])
+}
+
+func (t *SegmentTree[T]) Index(i int) (T, bool) {
+	if i < 0 || i >= t.n {
+		return *new(T), false
+	}
+	return t.query(1, 0, t.n, i, i+1), true
+}
+
+func (t *SegmentTree[T]) SetIndex(i int, x T) {
+	if i < 0 || i >= t.n {
+		panic(fmt.Sprintf("index %d out of range [0:%d]", i, t.n))
+	}
+	t.setRange(1, 0, t.n, i, i+1, x)
+}
+
+func (t *SegmentTree[T]) RangeKeys(f func(i int) bool) {
+	for i := 0; i < t.n; i++ {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (t *SegmentTree[T]) RangeElems(f func(x T) bool) {
+	for i := 0; i < t.n; i++ {
+		x, _ := t.Index(i)
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (t *SegmentTree[T]) Range(f func(i int, x T) bool) {
+	for i := 0; i < t.n; i++ {
+		x, _ := t.Index(i)
+		if !f(i, x) {
+			break
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"math/bits"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// The SizeBytes methods of the types in this package report the number of
+// bytes of memory referenced by the receiver: everything reachable from it,
+// but not the receiver value itself. For a pointer receiver that includes the
+// struct it points to; for a Slice it is the backing array (to its capacity)
+// plus the memory referenced by each element.
+
+// SizeOf returns an estimate of the memory occupied by x: the size of x
+// itself plus the memory reachable from it.
+//
+// SizeOf walks x using reflection. It consults, in order, a function
+// registered for the type with RegisterSize, or a SizeBytes method of the
+// type, before walking a value itself. Each pointer, backing array, map,
+// channel, and string is counted once per call, no matter how many times it
+// is reached.
+//
+// The result is an estimate, typically within a few tens of percent of the
+// memory actually retained:
+//   - Allocations are not rounded up to the allocator's size classes,
+//     which adds up to 12.5% to most objects.
+//   - Map sizes are computed from the current number of entries and the
+//     runtime's hash table layout. Maps never shrink, so a map that was once
+//     larger may occupy several times its estimate.
+//   - Values buffered in channels, the variables captured by closures, and
+//     the memory behind unsafe.Pointer fields are not counted.
+//   - A pointer into the middle of an object is counted as a separate object
+//     of the pointed-to type, and strings and slices that share backing
+//     arrays with different starting offsets are counted separately.
+//   - Values in interfaces are assumed to be boxed unless they are
+//     pointer-shaped, although the runtime avoids allocating for some
+//     small values.
+func SizeOf(x any) int64 {
+	if x == nil {
+		return 0
+	}
+	v := reflect.ValueOf(x)
+	var e sizeEstimator
+	return int64(v.Type().Size()) + e.referenced(v)
+}
+
+// RegisterSize registers f to report the number of bytes referenced by a
+// value of type T, that is, not counting the size of T itself. The estimator
+// used by SizeOf and the SizeBytes methods calls f instead of walking values
+// of type T, or calling their SizeBytes method.
+//
+// RegisterSize is consulted only for types that contain pointers,
+// since values of other types cannot reference any memory.
+func RegisterSize[T any](f func(T) int64) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	sizeOverrides.Store(t, func(v reflect.Value) int64 {
+		return f(v.Interface().(T))
+	})
+}
+
+var (
+	sizeOverrides sync.Map // reflect.Type → func(reflect.Value) int64
+	hasPointers   sync.Map // reflect.Type → bool
+)
+
+var sizerType = reflect.TypeOf((*Sizer)(nil)).Elem()
+
+// referencedSize returns the number of bytes referenced by x, walking it
+// directly even if it implements Sizer.
+func referencedSize(x any) int64 {
+	var e sizeEstimator
+	return e.walk(reflect.ValueOf(x))
+}
+
+// referencedAt returns the number of bytes referenced by *p,
+// not already counted by e.
+func referencedAt[T any](e *sizeEstimator, p *T) int64 {
+	return e.referenced(reflect.ValueOf(p).Elem())
+}
+
+// A sizeEstimator estimates the memory reachable from values,
+// counting each object at most once.
+//
+// The estimator keeps its pending work on an explicit stack rather than
+// recursing, so that deep structures such as long linked lists and
+// degenerate trees cannot overflow the goroutine stack.
+type sizeEstimator struct {
+	seen  map[sizeKey]bool
+	stack []sizeTask
+}
+
+type sizeKey struct {
+	p unsafe.Pointer
+	t reflect.Type
+}
+
+// A sizeTask is pending work for a sizeEstimator: a value whose referenced
+// memory is to be counted, the elements or fields of v from index i onward,
+// or the remaining entries of a map iteration.
+type sizeTask struct {
+	v    reflect.Value
+	i    int // -1 if v itself is to be counted
+	iter *reflect.MapIter
+}
+
+// visit reports whether the object of type t at p has not yet been counted,
+// and marks it as counted.
+func (e *sizeEstimator) visit(p unsafe.Pointer, t reflect.Type) bool {
+	k := sizeKey{p, t}
+	if e.seen[k] {
+		return false
+	}
+	if e.seen == nil {
+		e.seen = make(map[sizeKey]bool)
+	}
+	e.seen[k] = true
+	return true
+}
+
+// referenced returns the number of bytes referenced by v,
+// using a registered function or SizeBytes method if there is one.
+func (e *sizeEstimator) referenced(v reflect.Value) int64 {
+	base := len(e.stack)
+	return e.count(v) + e.drain(base)
+}
+
+// walk returns the number of bytes referenced by v,
+// examining v itself rather than any Sizer method it may have.
+func (e *sizeEstimator) walk(v reflect.Value) int64 {
+	base := len(e.stack)
+	return e.step(v) + e.drain(base)
+}
+
+// drain completes the tasks on e.stack above base,
+// and returns the number of bytes they count.
+func (e *sizeEstimator) drain(base int) int64 {
+	n := int64(0)
+	for len(e.stack) > base {
+		top := len(e.stack) - 1
+		task := e.stack[top]
+		switch {
+		case task.iter != nil:
+			if !task.iter.Next() {
+				e.stack = e.stack[:top]
+				continue
+			}
+			n += e.count(task.iter.Key()) + e.count(task.iter.Value())
+
+		case task.i >= 0:
+			var elem reflect.Value
+			if task.v.Kind() == reflect.Struct {
+				if task.i >= task.v.NumField() {
+					e.stack = e.stack[:top]
+					continue
+				}
+				elem = task.v.Field(task.i)
+			} else {
+				if task.i >= task.v.Len() {
+					e.stack = e.stack[:top]
+					continue
+				}
+				elem = task.v.Index(task.i)
+			}
+			e.stack[top].i++
+			n += e.count(elem)
+
+		default:
+			e.stack = e.stack[:top]
+			n += e.count(task.v)
+		}
+	}
+	return n
+}
+
+// count is like referenced, but instead of counting the values that v
+// refers to, it pushes them onto e.stack.
+func (e *sizeEstimator) count(v reflect.Value) int64 {
+	t := v.Type()
+	if !typeHasPointers(t) {
+		return 0
+	}
+	v = exported(v)
+	if f, ok := sizeOverrides.Load(t); ok {
+		return f.(func(reflect.Value) int64)(v)
+	}
+	if t.Implements(sizerType) {
+		switch v.Kind() {
+		case reflect.Pointer:
+			if v.IsNil() || !e.visit(v.UnsafePointer(), t) {
+				return 0
+			}
+		case reflect.Interface:
+			if v.IsNil() {
+				return 0
+			}
+		}
+		return v.Interface().(Sizer).SizeBytes()
+	}
+	return e.step(v)
+}
+
+// step is like walk, but instead of counting the values that v refers to,
+// it pushes them onto e.stack.
+func (e *sizeEstimator) step(v reflect.Value) int64 {
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || !e.visit(v.UnsafePointer(), t) {
+			return 0
+		}
+		e.push(v.Elem())
+		return int64(t.Elem().Size())
+
+	case reflect.String:
+		if v.Len() == 0 || !e.visit(unsafe.Pointer(unsafe.StringData(v.String())), t) {
+			return 0
+		}
+		return int64(v.Len())
+
+	case reflect.Slice:
+		if v.Cap() == 0 || !e.visit(v.UnsafePointer(), t) {
+			return 0
+		}
+		if typeHasPointers(t.Elem()) {
+			e.pushElems(v)
+		}
+		return int64(v.Cap()) * int64(t.Elem().Size())
+
+	case reflect.Array:
+		if typeHasPointers(t.Elem()) {
+			e.pushElems(addressable(v))
+		}
+		return 0
+
+	case reflect.Struct:
+		e.pushElems(addressable(v))
+		return 0
+
+	case reflect.Map:
+		if v.IsNil() || !e.visit(v.UnsafePointer(), t) {
+			return 0
+		}
+		if typeHasPointers(t.Key()) || typeHasPointers(t.Elem()) {
+			e.stack = append(e.stack, sizeTask{iter: v.MapRange()})
+		}
+		return mapSize(t, v.Len())
+
+	case reflect.Chan:
+		if v.IsNil() || !e.visit(v.UnsafePointer(), t) {
+			return 0
+		}
+		return chanHeaderSize + int64(v.Cap())*int64(t.Elem().Size())
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		e.push(elem)
+		switch elem.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			return 0
+		default:
+			return int64(elem.Type().Size())
+		}
+	}
+
+	// Funcs and unsafe.Pointers may reference memory, but we cannot tell how
+	// much; everything else is stored inline.
+	return 0
+}
+
+// push schedules the memory referenced by v to be counted.
+func (e *sizeEstimator) push(v reflect.Value) {
+	e.stack = append(e.stack, sizeTask{v: v, i: -1})
+}
+
+// pushElems schedules the memory referenced by each element or field of v,
+// which must be a slice, array, or struct, to be counted.
+func (e *sizeEstimator) pushElems(v reflect.Value) {
+	e.stack = append(e.stack, sizeTask{v: v})
+}
+
+// exported returns v without the read-only flag that reflect sets on values
+// obtained through unexported fields, so that it can be passed to Interface.
+func exported(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// addressable returns an addressable copy of v if v is not addressable,
+// so that exported can be applied to its fields and elements.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// typeHasPointers reports whether values of type t may reference memory
+// outside themselves.
+func typeHasPointers(t reflect.Type) bool {
+	if b, ok := hasPointers.Load(t); ok {
+		return b.(bool)
+	}
+	var b bool
+	switch t.Kind() {
+	case reflect.Pointer, reflect.String, reflect.Slice, reflect.Map,
+		reflect.Chan, reflect.Interface, reflect.Func, reflect.UnsafePointer:
+		b = true
+	case reflect.Array:
+		b = t.Len() > 0 && typeHasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if typeHasPointers(t.Field(i).Type) {
+				b = true
+				break
+			}
+		}
+	}
+	hasPointers.Store(t, b)
+	return b
+}
+
+const (
+	chanHeaderSize = 96  // size of the runtime's hchan
+	mapHeaderSize  = 48  // size of the runtime's map header
+	mapGroupSlots  = 8   // slots per group of a hash table
+	mapMaxInline   = 128 // larger keys and values are stored indirectly
+)
+
+// mapSize estimates the memory occupied by a map of type t with n entries,
+// not counting the memory referenced by its keys and values.
+//
+// It models the Swiss-table maps used by the runtime since Go 1.24, in which
+// entries are stored in groups of 8 slots, each with a control byte, rather
+// than the buckets and overflow buckets of earlier releases. The estimate
+// treats the map as a single table: it omits the directory and per-table
+// headers of maps that have grown past 1024 slots and split into several
+// tables, which add a small fraction to the size of such maps.
+func mapSize(t reflect.Type, n int) int64 {
+	size := int64(mapHeaderSize)
+	if n == 0 {
+		return size
+	}
+
+	// Hash tables are kept at most 7/8 full,
+	// with a power-of-two number of slots.
+	slots := int64(mapGroupSlots)
+	if need := (n*8 + 6) / 7; need > mapGroupSlots {
+		slots = 1 << bits.Len(uint(need-1))
+	}
+
+	slot := int64(0)
+	for _, kt := range []reflect.Type{t.Key(), t.Elem()} {
+		if kt.Size() > mapMaxInline {
+			size += int64(n) * int64(kt.Size())
+			slot += int64(unsafe.Sizeof(uintptr(0)))
+		} else {
+			slot += int64(kt.Size())
+		}
+	}
+	// Each group has one control byte per slot.
+	return size + slots*(slot+1)
+}
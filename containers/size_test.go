@@ -0,0 +1,153 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var _ = []containers.Sizer{
+	containers.String(""),
+	containers.Slice[int](nil),
+	containers.Map[int, int](nil),
+	containers.Chan[int](nil),
+	containers.RecvChan[int](nil),
+	containers.SendChan[int](nil),
+	(*containers.BloomFilter[string])(nil),
+	(*containers.ChunkedSlice[int])(nil),
+	(*containers.COWMap[int, int])(nil),
+	(*containers.COWSlice[int])(nil),
+	(*containers.CountMinSketch[string])(nil),
+	(*containers.DisjointSet[int])(nil),
+	(*containers.FenwickTree[int])(nil),
+	(*containers.HyperLogLog[string])(nil),
+	(*containers.Interner[string])(nil),
+	(*containers.KDTree[int])(nil),
+	(*containers.List[int])(nil),
+	containers.Matrix[int]{},
+	(*containers.Multiset[int])(nil),
+	containers.Rope{},
+	(*containers.RTree[int])(nil),
+	(*containers.SegmentTree[int])(nil),
+	(*containers.SlotMap[int])(nil),
+	(*containers.SmallVec[int])(nil),
+	(*containers.Table[int])(nil),
+	(*containers.UniqueIndex[int, int])(nil),
+	(*containers.MultiIndex[int, int])(nil),
+	(*containers.OrderedIndex[int, int])(nil),
+	(*containers.TTLMap[int, int])(nil),
+	(*containers.Transactional[int, int])(nil),
+	(*containers.UnboundedChan[int])(nil),
+	containers.Vector[int]{},
+}
+
+func TestSizeBytes(t *testing.T) {
+	s := make(containers.Slice[string], 2, 10)
+	s[0] = strings.Repeat("x", 100)
+	s[1] = s[0] // shared, so counted once
+	if got, want := s.SizeBytes(), int64(10*unsafe.Sizeof("")+100); got != want {
+		t.Errorf("Slice.SizeBytes() = %d; want %d", got, want)
+	}
+
+	type node struct {
+		next  *node
+		label string
+	}
+	a := &node{label: "aaaa"}
+	a.next = &node{next: a, label: "bb"}
+	if got, want := containers.SizeOf(a), int64(8+2*unsafe.Sizeof(node{})+6); got != want {
+		t.Errorf("SizeOf(cycle) = %d; want %d", got, want)
+	}
+
+	var l containers.List[[]byte]
+	for i := 0; i < 100000; i++ {
+		l.PushBack(make([]byte, 10))
+	}
+	if got, min := l.SizeBytes(), int64(100000*(10+unsafe.Sizeof([]byte(nil)))); got < min {
+		t.Errorf("List.SizeBytes() = %d; want at least %d", got, min)
+	}
+}
+
+type opaque struct{ p unsafe.Pointer }
+
+func TestRegisterSize(t *testing.T) {
+	containers.RegisterSize(func(opaque) int64 { return 1000 })
+
+	var m containers.COWMap[int, opaque]
+	m.SetIndex(1, opaque{})
+	m.SetIndex(2, opaque{})
+	if got := m.SizeBytes(); got < 2000 {
+		t.Errorf("COWMap.SizeBytes() = %d; want at least 2000 from registered sizes", got)
+	}
+}
+
+func TestMapSizeEstimate(t *testing.T) {
+	// The estimate models the runtime's hash tables: a 48-byte header, plus
+	// a power-of-two number of slots (at least 8) that keeps the table at
+	// most 7/8 full, each slot holding a key, a value, and a control byte.
+	for _, tc := range []struct {
+		n    int
+		want int64
+	}{
+		{0, 48},
+		{1, 48 + 8*(16+1)},
+		{7, 48 + 8*(16+1)},
+		{8, 48 + 16*(16+1)},
+		{14, 48 + 16*(16+1)},
+		{15, 48 + 32*(16+1)},
+		{100000, 48 + 131072*(16+1)},
+	} {
+		m := make(containers.Map[int64, int64])
+		for i := int64(0); i < int64(tc.n); i++ {
+			m[i] = i
+		}
+		if got := m.SizeBytes(); got != tc.want {
+			t.Errorf("Map.SizeBytes() with %d entries = %d; want %d", tc.n, got, tc.want)
+		}
+	}
+
+	// Keys and values larger than 128 bytes are stored indirectly:
+	// each slot holds a pointer to a separate allocation.
+	m := make(containers.Map[int64, [200]byte])
+	for i := int64(0); i < 10; i++ {
+		m[i] = [200]byte{}
+	}
+	if got, want := m.SizeBytes(), int64(48+10*200+16*(8+8+1)); got != want {
+		t.Errorf("Map.SizeBytes() with 10 large values = %d; want %d", got, want)
+	}
+}
+
+func TestSizeBytesDeep(t *testing.T) {
+	// Limit the stack so that an estimator that recursed once per level
+	// of a deep structure would overflow it.
+	defer debug.SetMaxStack(debug.SetMaxStack(4 << 20))
+
+	// Inserting sorted points degenerates a KDTree into a linked chain.
+	const n = 10000
+	points := make([]containers.Point, n)
+	values := make([]int, n)
+	sorted := containers.NewKDTree[int](1)
+	for i := range points {
+		points[i] = containers.Point{float64(i)}
+		values[i] = i
+		sorted.Insert(points[i], i)
+	}
+	balanced := containers.NewKDTree[int](1)
+	balanced.Load(points, values)
+
+	// Both trees hold the same nodes, so they have the same size.
+	got, want := sorted.SizeBytes(), balanced.SizeBytes()
+	if got != want {
+		t.Errorf("SizeBytes() of a degenerate KDTree = %d; want %d, as for a balanced one", got, want)
+	}
+	if min := int64(n * (8 + unsafe.Sizeof(containers.Point{}))); got < min {
+		t.Errorf("KDTree.SizeBytes() = %d; want at least %d", got, min)
+	}
+}
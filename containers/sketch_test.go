@@ -0,0 +1,119 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+func TestBloomFilter(t *testing.T) {
+	const n = 10000
+	m, k := containers.BloomFilterSize(n, 0.01)
+	a := containers.NewBloomFilter(m, k, containers.HashString)
+	b := containers.NewBloomFilter(m, k, containers.HashString)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			a.Add(strconv.Itoa(i))
+		} else {
+			b.Add(strconv.Itoa(i))
+		}
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := containers.NewBloomFilter(1, 1, containers.HashString)
+	if err := f.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		if !f.MayContain(strconv.Itoa(i)) {
+			t.Fatalf("MayContain(%q) = false after Add", strconv.Itoa(i))
+		}
+	}
+	fp := 0
+	for i := 0; i < n; i++ {
+		if f.MayContain("x" + strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	if fp > n/50 {
+		t.Errorf("%d false positives in %d queries; want about 1%%", fp, n)
+	}
+}
+
+func TestCountMinSketch(t *testing.T) {
+	s := containers.NewCountMinSketch(1024, 4, containers.HashUint64)
+	for i := uint64(0); i < 10000; i++ {
+		s.Add(i%100, 1)
+	}
+	s.Add(7, 50)
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := containers.NewCountMinSketch(1, 1, containers.HashUint64)
+	if err := u.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Merge(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := u.Estimate(7); got < 300 {
+		t.Errorf("Estimate(7) = %d; want at least 300", got)
+	}
+	if got := u.Estimate(1000); got > 100 {
+		t.Errorf("Estimate(1000) = %d; want near 0", got)
+	}
+}
+
+func TestCountMinSketchOverflow(t *testing.T) {
+	// Each dimension fits in a uint32, but their product overflows int.
+	n := int(^uint32(0))
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.HasPrefix(msg, "NewCountMinSketch: ") {
+			t.Errorf("NewCountMinSketch(%d, %d) panicked with %q; want a NewCountMinSketch error", n, n, msg)
+		}
+	}()
+	containers.NewCountMinSketch(n, n, containers.HashUint64)
+}
+
+func TestHyperLogLog(t *testing.T) {
+	a := containers.NewHyperLogLog(14, containers.HashString)
+	b := containers.NewHyperLogLog(14, containers.HashString)
+	for i := 0; i < 100000; i++ {
+		a.Add(strconv.Itoa(i))
+		b.Add(strconv.Itoa(i + 50000))
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := containers.NewHyperLogLog(4, containers.HashString)
+	if err := h.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	const want = 150000
+	if got := h.Count(); got < want*97/100 || got > want*103/100 {
+		t.Errorf("Count() = %d; want %d ± 3%%", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+// A SlotKey is an opaque handle to a value in a SlotMap.
+// The zero SlotKey never refers to a value.
+type SlotKey struct {
+	index uint32
+	gen   uint32
+}
+
+// A SlotMap stores values densely, and identifies them by SlotKeys that
+// remain stable while the value is present and become invalid once it is
+// removed, even if the slot is later reused.
+//
+// Insert and Remove take constant time, and the values are kept contiguous
+// so that ranging over them is as fast as ranging over a slice. Ranging
+// visits values in an arbitrary order that changes as values are removed.
+// The zero SlotMap is empty and ready to use.
+type SlotMap[T any] struct {
+	slots    []slot
+	values   []T
+	owners   []uint32 // owners[i] is the slot index of values[i]
+	freeHead uint32   // 1 + index of the first free slot, or 0 if none
+}
+
+type slot struct {
+	gen  uint32 // incremented each time the slot is vacated
+	pos  uint32 // for an occupied slot, the position of its value
+	next uint32 // for a free slot, 1 + index of the next free slot, or 0
+	used bool
+}
+
+func (m *SlotMap[T]) Len() int         { return len(m.values) }
+func (m *SlotMap[T]) SizeBytes() int64 { return referencedSize(m) }
+
+// Insert adds x to m and returns its key.
+func (m *SlotMap[T]) Insert(x T) SlotKey {
+	var i uint32
+	if m.freeHead != 0 {
+		i = m.freeHead - 1
+		m.freeHead = m.slots[i].next
+	} else {
+		i = uint32(len(m.slots))
+		m.slots = append(m.slots, slot{gen: 1})
+	}
+	s := &m.slots[i]
+	s.used = true
+	s.pos = uint32(len(m.values))
+	s.next = 0
+	m.values = append(m.values, x)
+	m.owners = append(m.owners, i)
+	return SlotKey{index: i, gen: s.gen}
+}
+
+// lookup returns the slot for k, or nil if k is stale or invalid.
+func (m *SlotMap[T]) lookup(k SlotKey) *slot {
+	if int(k.index) >= len(m.slots) {
+		return nil
+	}
+	s := &m.slots[k.index]
+	if !s.used || s.gen != k.gen {
+		return nil
+	}
+	return s
+}
+
+// Contains reports whether k refers to a value in m.
+func (m *SlotMap[T]) Contains(k SlotKey) bool { return m.lookup(k) != nil }
+
+// Index returns the value for k. It reports false if k has been removed.
+func (m *SlotMap[T]) Index(k SlotKey) (T, bool) {
+	s := m.lookup(k)
+	if s == nil {
+		return *new(T), false
+	}
+	return m.values[s.pos], true
+}
+
+// Set replaces the value for k, and reports whether k refers to a value in m.
+func (m *SlotMap[T]) Set(k SlotKey, x T) bool {
+	s := m.lookup(k)
+	if s == nil {
+		return false
+	}
+	m.values[s.pos] = x
+	return true
+}
+
+// Remove removes the value for k, and reports whether it was present.
+// Once removed, k is permanently invalid.
+func (m *SlotMap[T]) Remove(k SlotKey) (T, bool) {
+	s := m.lookup(k)
+	if s == nil {
+		return *new(T), false
+	}
+	pos := s.pos
+	x := m.values[pos]
+
+	// Move the last value into the vacated position.
+	last := uint32(len(m.values) - 1)
+	if pos != last {
+		m.values[pos] = m.values[last]
+		m.owners[pos] = m.owners[last]
+		m.slots[m.owners[pos]].pos = pos
+	}
+	m.values[last] = *new(T)
+	m.values = m.values[:last]
+	m.owners = m.owners[:last]
+
+	s.used = false
+	s.gen++
+	if s.gen == 0 {
+		// The generation wrapped around; skip the zero generation so that
+		// the zero SlotKey remains invalid.
+		s.gen = 1
+	}
+	s.next = m.freeHead
+	m.freeHead = k.index + 1
+	return x, true
+}
+
+// Values returns the values of m as a contiguous Slice, in the order used by
+// the Range methods. The Slice is valid only until m is next modified.
+func (m *SlotMap[T]) Values() Slice[T] { return m.values }
+
+func (m *SlotMap[T]) key(pos int) SlotKey {
+	i := m.owners[pos]
+	return SlotKey{index: i, gen: m.slots[i].gen}
+}
+
+func (m *SlotMap[T]) RangeKeys(f func(SlotKey) bool) {
+	for pos := range m.values {
+		if !f(m.key(pos)) {
+			break
+		}
+	}
+}
+
+func (m *SlotMap[T]) RangeElems(f func(T) bool) {
+	for _, x := range m.values {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (m *SlotMap[T]) Range(f func(SlotKey, T) bool) {
+	for pos, x := range m.values {
+		if !f(m.key(pos), x) {
+			break
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"maps"
+	"math/rand"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Lenner                           = (*containers.SlotMap[int])(nil)
+	_ containers.Indexer[containers.SlotKey, int] = (*containers.SlotMap[int])(nil)
+	_ containers.Ranger[containers.SlotKey, int]  = (*containers.SlotMap[int])(nil)
+)
+
+func TestSlotMapStaleKey(t *testing.T) {
+	var m containers.SlotMap[string]
+	if m.Contains(containers.SlotKey{}) {
+		t.Errorf("zero SlotMap contains the zero SlotKey")
+	}
+
+	a := m.Insert("a")
+	b := m.Insert("b")
+	if x, ok := m.Remove(a); !ok || x != "a" {
+		t.Errorf(`Remove(a) = %q, %v; want "a", true`, x, ok)
+	}
+
+	// The freed slot is reused, but the old key does not refer to the new value.
+	c := m.Insert("c")
+	if c == a {
+		t.Fatalf("Insert after Remove returned the removed key %v", a)
+	}
+	if m.Contains(a) {
+		t.Errorf("Contains(a) after Remove = true")
+	}
+	if x, ok := m.Index(a); ok {
+		t.Errorf("Index(a) after Remove = %q, true; want false", x)
+	}
+	if m.Set(a, "stale") {
+		t.Errorf("Set(a) after Remove = true")
+	}
+	if x, ok := m.Remove(a); ok {
+		t.Errorf("second Remove(a) = %q, true; want false", x)
+	}
+	if m.Contains(containers.SlotKey{}) {
+		t.Errorf("SlotMap contains the zero SlotKey")
+	}
+
+	// The live keys are unaffected.
+	if x, _ := m.Index(c); x != "c" {
+		t.Errorf(`Index(c) = %q; want "c"`, x)
+	}
+	if !m.Set(b, "B") {
+		t.Errorf("Set(b) = false")
+	}
+	if x, _ := m.Index(b); x != "B" {
+		t.Errorf(`Index(b) = %q; want "B"`, x)
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d; want 2", m.Len())
+	}
+}
+
+func TestSlotMapRandom(t *testing.T) {
+	var m containers.SlotMap[int]
+	want := make(map[containers.SlotKey]int)
+	var removed []containers.SlotKey
+	r := rand.New(rand.NewSource(1))
+
+	for step := 0; step < 2000; step++ {
+		if len(want) == 0 || r.Intn(3) != 0 {
+			k := m.Insert(step)
+			if _, ok := want[k]; ok {
+				t.Fatalf("Insert returned live key %v", k)
+			}
+			want[k] = step
+			continue
+		}
+		var k containers.SlotKey
+		for k = range want {
+			break
+		}
+		if x, ok := m.Remove(k); !ok || x != want[k] {
+			t.Fatalf("Remove(%v) = %d, %v; want %d, true", k, x, ok, want[k])
+		}
+		delete(want, k)
+		removed = append(removed, k)
+	}
+
+	for _, k := range removed {
+		if _, live := want[k]; live {
+			t.Fatalf("removed key %v was reissued", k)
+		}
+		if m.Contains(k) {
+			t.Fatalf("Contains(%v) after Remove = true", k)
+		}
+	}
+
+	got := make(map[containers.SlotKey]int)
+	m.Range(func(k containers.SlotKey, x int) bool {
+		got[k] = x
+		return true
+	})
+	if !maps.Equal(got, want) {
+		t.Errorf("Range visited %d entries, want %d; contents differ", len(got), len(want))
+	}
+	if m.Len() != len(want) || len(m.Values()) != len(want) {
+		t.Errorf("Len(), len(Values()) = %d, %d; want %d", m.Len(), len(m.Values()), len(want))
+	}
+}
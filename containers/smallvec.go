@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import "fmt"
+
+// SmallVecInline is the number of elements a SmallVec stores inline.
+const SmallVecInline = 4
+
+// A SmallVec is a sequence that stores up to SmallVecInline elements within
+// the SmallVec itself, and moves them to a heap-allocated slice only when it
+// grows beyond that. A SmallVec that stays small and does not escape never
+// allocates.
+//
+// The zero SmallVec is empty and ready to use.
+// A SmallVec must not be copied after first use.
+type SmallVec[T any] struct {
+	inline [SmallVecInline]T
+	n      int // number of inline elements, if heap is nil
+	heap   []T
+}
+
+func (v *SmallVec[T]) Len() int {
+	if v.heap != nil {
+		return len(v.heap)
+	}
+	return v.n
+}
+
+func (v *SmallVec[T]) SizeBytes() int64 { return referencedSize(v) }
+
+func (v *SmallVec[T]) Cap() int {
+	if v.heap != nil {
+		return cap(v.heap)
+	}
+	return SmallVecInline
+}
+
+// Spilled reports whether v has moved its elements to the heap.
+func (v *SmallVec[T]) Spilled() bool { return v.heap != nil }
+
+func (v *SmallVec[T]) Index(i int) (T, bool) {
+	if i < 0 || i >= v.Len() {
+		return *new(T), false
+	}
+	if v.heap != nil {
+		return v.heap[i], true
+	}
+	return v.inline[i], true
+}
+
+func (v *SmallVec[T]) SetIndex(i int, x T) {
+	if i < 0 || i >= v.Len() {
+		panic(fmt.Sprintf("index %d out of range [0:%d]", i, v.Len()))
+	}
+	if v.heap != nil {
+		v.heap[i] = x
+	} else {
+		v.inline[i] = x
+	}
+}
+
+// Append appends xs to v, spilling to the heap if they do not fit inline.
+func (v *SmallVec[T]) Append(xs ...T) {
+	if v.heap == nil {
+		if v.n+len(xs) <= SmallVecInline {
+			v.n += copy(v.inline[v.n:], xs)
+			return
+		}
+		v.heap = make([]T, v.n, max(2*SmallVecInline, v.n+len(xs)))
+		copy(v.heap, v.inline[:v.n])
+		v.inline = [SmallVecInline]T{}
+		v.n = 0
+	}
+	v.heap = append(v.heap, xs...)
+}
+
+// Reset removes all elements from v, retaining any heap storage.
+func (v *SmallVec[T]) Reset() {
+	if v.heap != nil {
+		clear(v.heap)
+		v.heap = v.heap[:0]
+		return
+	}
+	v.inline = [SmallVecInline]T{}
+	v.n = 0
+}
+
+// Slice returns the elements of v as a Slice aliasing its storage.
+// The Slice is invalidated by the next call to Append.
+//
+// Calling Slice on a SmallVec that has not spilled causes the SmallVec
+// to escape to the heap if the Slice does.
+func (v *SmallVec[T]) Slice() Slice[T] {
+	if v.heap != nil {
+		return v.heap
+	}
+	return v.inline[:v.n:v.n]
+}
+
+func (v *SmallVec[T]) RangeKeys(f func(i int) bool) {
+	for i := 0; i < v.Len(); i++ {
+		if !f(i) {
+			break
+		}
+	}
+}
+
+func (v *SmallVec[T]) RangeElems(f func(x T) bool) {
+	v.Range(func(_ int, x T) bool { return f(x) })
+}
+
+func (v *SmallVec[T]) Range(f func(i int, x T) bool) {
+	for i := 0; i < v.Len(); i++ {
+		x, _ := v.Index(i)
+		if !f(i, x) {
+			break
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Lenner                = (*containers.SmallVec[int])(nil)
+	_ containers.Capper                = (*containers.SmallVec[int])(nil)
+	_ containers.IndexSetter[int, int] = (*containers.SmallVec[int])(nil)
+	_ containers.Ranger[int, int]      = (*containers.SmallVec[int])(nil)
+)
+
+func TestSmallVec(t *testing.T) {
+	var v containers.SmallVec[int]
+	for i := 0; i < 10; i++ {
+		v.Append(i)
+		if got, want := v.Spilled(), i >= containers.SmallVecInline; got != want {
+			t.Fatalf("after %d Appends, Spilled() = %v; want %v", i+1, got, want)
+		}
+		v.SetIndex(i, 10*i)
+	}
+	if v.Len() != 10 {
+		t.Fatalf("Len() = %d; want 10", v.Len())
+	}
+	v.Range(func(i, x int) bool {
+		if x != 10*i {
+			t.Errorf("element %d = %d; want %d", i, x, 10*i)
+		}
+		return true
+	})
+	if _, ok := v.Index(10); ok {
+		t.Errorf("Index(10) succeeded; want false")
+	}
+}
+
+func sumSmallVec(n int) int {
+	var v containers.SmallVec[int]
+	for i := 0; i < n; i++ {
+		v.Append(i)
+	}
+	sum := 0
+	v.RangeElems(func(x int) bool {
+		sum += x
+		return true
+	})
+	return sum
+}
+
+func TestSmallVecInlineAllocs(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() { sumSmallVec(containers.SmallVecInline) })
+	if allocs != 0 {
+		t.Errorf("inline SmallVec made %v allocations; want 0", allocs)
+	}
+}
+
+func BenchmarkSmallVec(b *testing.B) {
+	for _, n := range []int{containers.SmallVecInline, 4 * containers.SmallVecInline} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				sumSmallVec(n)
+			}
+		})
+	}
+}
+
+func BenchmarkSlice(b *testing.B) {
+	for _, n := range []int{containers.SmallVecInline, 4 * containers.SmallVecInline} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var s containers.Slice[int]
+				for j := 0; j < n; j++ {
+					s = append(s, j)
+				}
+				sink = s
+			}
+		})
+	}
+}
+
+var sink containers.Slice[int]
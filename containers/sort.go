@@ -0,0 +1,119 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"math/bits"
+	"math/rand"
+	"slices"
+)
+
+// A Sortable is an indexed sequence whose elements can be reordered in place.
+type Sortable[T any] interface {
+	Lenner
+	IndexSetter[int, T]
+}
+
+func at[T any](s Sequence[T], i int) T {
+	x, _ := s.Index(i)
+	return x
+}
+
+func sortableLessSwap[T any](s Sortable[T], cmp func(a, b T) int) lessSwap {
+	return lessSwap{
+		Less: func(i, j int) bool { return cmp(at[T](s, i), at[T](s, j)) < 0 },
+		Swap: func(i, j int) { swapIndex(s, i, j) },
+	}
+}
+
+func swapIndex[T any](s Sortable[T], i, j int) {
+	x, y := at[T](s, i), at[T](s, j)
+	s.SetIndex(i, y)
+	s.SetIndex(j, x)
+}
+
+// Sort sorts s in ascending order as determined by cmp, which must be a
+// strict weak ordering as for slices.SortFunc. The sort is not stable.
+//
+// Sort uses pattern-defeating quicksort, making O(n log n) calls to cmp and
+// to s.Index and s.SetIndex.
+func Sort[T any](s Sortable[T], cmp func(a, b T) int) {
+	if x, ok := s.(Slice[T]); ok {
+		slices.SortFunc(x, cmp)
+		return
+	}
+	n := s.Len()
+	pdqsort(sortableLessSwap(s, cmp), 0, n, bits.Len(uint(n)))
+}
+
+// SortStable sorts s in ascending order as determined by cmp, keeping the
+// original order of equal elements.
+//
+// SortStable does not allocate: it merges in place using rotations, making
+// O(n log n) calls to cmp and O(n log² n) calls to s.Index and s.SetIndex.
+func SortStable[T any](s Sortable[T], cmp func(a, b T) int) {
+	if x, ok := s.(Slice[T]); ok {
+		slices.SortStableFunc(x, cmp)
+		return
+	}
+	stable(sortableLessSwap(s, cmp), s.Len())
+}
+
+// IsSorted reports whether s is sorted in ascending order as determined by
+// cmp.
+func IsSorted[T any](s Sequence[T], cmp func(a, b T) int) bool {
+	if x, ok := s.(Slice[T]); ok {
+		return slices.IsSortedFunc(x, cmp)
+	}
+	for i := s.Len() - 1; i > 0; i-- {
+		if cmp(at(s, i), at(s, i-1)) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// BinarySearch searches for target in s, which must be sorted in ascending
+// order as determined by cmp. It returns the position where target is found,
+// or the position where it would be inserted, and reports whether target was
+// found, as for slices.BinarySearchFunc.
+func BinarySearch[T, K any](s Sequence[T], target K, cmp func(T, K) int) (int, bool) {
+	if x, ok := s.(Slice[T]); ok {
+		return slices.BinarySearchFunc(x, target, cmp)
+	}
+	n := s.Len()
+	lo, hi := 0, n
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		if cmp(at(s, m), target) < 0 {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	return lo, lo < n && cmp(at(s, lo), target) == 0
+}
+
+// Reverse reverses the elements of s.
+func Reverse[T any](s Sortable[T]) {
+	if x, ok := s.(Slice[T]); ok {
+		slices.Reverse(x)
+		return
+	}
+	for i, j := 0, s.Len()-1; i < j; i, j = i+1, j-1 {
+		swapIndex(s, i, j)
+	}
+}
+
+// Shuffle pseudo-randomly permutes the elements of s.
+// The permutation is determined by seed and the length of s.
+func Shuffle[T any](s Sortable[T], seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	if x, ok := s.(Slice[T]); ok {
+		r.Shuffle(len(x), func(i, j int) { x[i], x[j] = x[j], x[i] })
+		return
+	}
+	r.Shuffle(s.Len(), func(i, j int) { swapIndex(s, i, j) })
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// indexed hides the concrete type of a Slice from the fast paths.
+type indexed[T any] struct{ s containers.Slice[T] }
+
+func (x indexed[T]) Len() int              { return x.s.Len() }
+func (x indexed[T]) Index(i int) (T, bool) { return x.s.Index(i) }
+func (x indexed[T]) SetIndex(i int, v T)   { x.s.SetIndex(i, v) }
+
+type pair struct{ key, seq int }
+
+func cmpKey(a, b pair) int { return cmp.Compare(a.key, b.key) }
+
+func TestSort(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 11, 12, 13, 100, 1000, 5000} {
+		r := rand.New(rand.NewSource(int64(n)))
+		orig := make(containers.Slice[pair], n)
+		for i := range orig {
+			orig[i] = pair{r.Intn(n/4 + 1), i}
+		}
+		want := slices.Clone(orig)
+		slices.SortStableFunc(want, cmpKey)
+
+		for _, stable := range []bool{false, true} {
+			s := slices.Clone(orig)
+			if stable {
+				containers.SortStable[pair](indexed[pair]{s}, cmpKey)
+			} else {
+				containers.Sort[pair](indexed[pair]{s}, cmpKey)
+			}
+			if !containers.IsSorted[pair](indexed[pair]{s}, cmpKey) {
+				t.Fatalf("n=%d, stable=%v: result not sorted", n, stable)
+			}
+			if stable && !slices.Equal(s, want) {
+				t.Fatalf("n=%d: SortStable did not preserve the order of equal elements", n)
+			}
+		}
+	}
+}
+
+func TestBinarySearch(t *testing.T) {
+	s := indexed[int]{containers.Slice[int]{1, 3, 3, 5, 8}}
+	for _, tt := range []struct {
+		target, i int
+		found     bool
+	}{
+		{0, 0, false}, {1, 0, true}, {3, 1, true}, {4, 3, false}, {8, 4, true}, {9, 5, false},
+	} {
+		i, found := containers.BinarySearch[int](s, tt.target, cmp.Compare[int])
+		if i != tt.i || found != tt.found {
+			t.Errorf("BinarySearch(%d) = %d, %v; want %d, %v", tt.target, i, found, tt.i, tt.found)
+		}
+	}
+}
+
+func TestShuffleReverse(t *testing.T) {
+	a := containers.Slice[int]{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	b := slices.Clone(a)
+	containers.Shuffle[int](a, 42)
+	containers.Shuffle[int](indexed[int]{b}, 42)
+	if !slices.Equal(a, b) {
+		t.Fatalf("Shuffle with the same seed produced %v and %v", a, b)
+	}
+
+	containers.Reverse[int](a)
+	containers.Reverse[int](indexed[int]{b})
+	if !slices.Equal(a, b) {
+		t.Fatalf("Reverse produced %v and %v", a, b)
+	}
+}
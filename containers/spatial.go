@@ -0,0 +1,184 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"fmt"
+	"math"
+)
+
+// A Point is a position in N-dimensional space.
+type Point []float64
+
+// A Rect is an axis-aligned N-dimensional box, including its boundary.
+type Rect struct {
+	Min, Max Point
+}
+
+// PointRect returns the degenerate Rect containing only p.
+func PointRect(p Point) Rect { return Rect{Min: p, Max: p} }
+
+// Intersects reports whether r and s have any points in common.
+func (r Rect) Intersects(s Rect) bool {
+	for i := range r.Min {
+		if r.Min[i] > s.Max[i] || s.Min[i] > r.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains reports whether s lies entirely within r.
+func (r Rect) Contains(s Rect) bool {
+	for i := range r.Min {
+		if s.Min[i] < r.Min[i] || s.Max[i] > r.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns the smallest Rect containing both r and s.
+func (r Rect) Union(s Rect) Rect {
+	u := Rect{Min: make(Point, len(r.Min)), Max: make(Point, len(r.Max))}
+	for i := range r.Min {
+		u.Min[i] = math.Min(r.Min[i], s.Min[i])
+		u.Max[i] = math.Max(r.Max[i], s.Max[i])
+	}
+	return u
+}
+
+// Volume returns the N-dimensional volume of r.
+func (r Rect) Volume() float64 {
+	v := 1.0
+	for i := range r.Min {
+		v *= r.Max[i] - r.Min[i]
+	}
+	return v
+}
+
+func (r Rect) center(i int) float64 { return (r.Min[i] + r.Max[i]) / 2 }
+
+// equal reports whether r and s have identical bounds.
+func (r Rect) equal(s Rect) bool {
+	for i := range r.Min {
+		if r.Min[i] != s.Min[i] || r.Max[i] != s.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// dist2 returns the squared Euclidean distance from p to the nearest point
+// of r, which is zero if r contains p.
+func (r Rect) dist2(p Point) float64 {
+	var d float64
+	for i, x := range p {
+		switch {
+		case x < r.Min[i]:
+			d += (r.Min[i] - x) * (r.Min[i] - x)
+		case x > r.Max[i]:
+			d += (x - r.Max[i]) * (x - r.Max[i])
+		}
+	}
+	return d
+}
+
+func dist2(p, q Point) float64 {
+	var d float64
+	for i := range p {
+		d += (p[i] - q[i]) * (p[i] - q[i])
+	}
+	return d
+}
+
+func checkDims(op string, want int, p Point) {
+	if len(p) != want {
+		panic(fmt.Sprintf("%s: point has %d dimensions; want %d", op, len(p), want))
+	}
+}
+
+func checkRectDims(op string, want int, r Rect) {
+	checkDims(op, want, r.Min)
+	checkDims(op, want, r.Max)
+}
+
+// A spatialQuery is a lazily-evaluated query result.
+type spatialQuery[T any] func(f func(T) bool)
+
+func (q spatialQuery[T]) RangeElems(f func(T) bool) { q(f) }
+
+// A nearestHeap is a bounded max-heap of candidates by distance,
+// used to collect the k nearest neighbors of a point.
+type nearestHeap[T any] struct {
+	k     int
+	dists []float64
+	vals  []T
+}
+
+// full reports whether h holds k candidates.
+func (h *nearestHeap[T]) full() bool { return len(h.dists) == h.k }
+
+// bound returns the squared distance that a new candidate must beat.
+func (h *nearestHeap[T]) bound() float64 {
+	if !h.full() {
+		return math.Inf(1)
+	}
+	return h.dists[0]
+}
+
+func (h *nearestHeap[T]) offer(d float64, v T) {
+	if !h.full() {
+		h.dists = append(h.dists, d)
+		h.vals = append(h.vals, v)
+		for i := len(h.dists) - 1; i > 0; {
+			parent := (i - 1) / 2
+			if h.dists[parent] >= h.dists[i] {
+				break
+			}
+			h.swap(i, parent)
+			i = parent
+		}
+		return
+	}
+	if d >= h.dists[0] {
+		return
+	}
+	h.dists[0], h.vals[0] = d, v
+	h.down(0, len(h.dists))
+}
+
+func (h *nearestHeap[T]) swap(i, j int) {
+	h.dists[i], h.dists[j] = h.dists[j], h.dists[i]
+	h.vals[i], h.vals[j] = h.vals[j], h.vals[i]
+}
+
+func (h *nearestHeap[T]) down(i, n int) {
+	for {
+		l := 2*i + 1
+		if l >= n {
+			return
+		}
+		j := l
+		if r := l + 1; r < n && h.dists[r] > h.dists[l] {
+			j = r
+		}
+		if h.dists[i] >= h.dists[j] {
+			return
+		}
+		h.swap(i, j)
+		i = j
+	}
+}
+
+// sorted returns the candidates in increasing order of distance,
+// consuming h.
+func (h *nearestHeap[T]) sorted() Slice[T] {
+	for n := len(h.dists) - 1; n > 0; n-- {
+		h.swap(0, n)
+		h.down(0, n)
+	}
+	return h.vals
+}
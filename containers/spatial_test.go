@@ -0,0 +1,211 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// A spatialIndex is the query API shared by KDTree and RTree.
+type spatialIndex interface {
+	Len() int
+	RangeElems(func(int) bool)
+	Nearest(p containers.Point, k int) containers.Slice[int]
+	WithinRadius(p containers.Point, r float64) containers.ElemRanger[int]
+	Intersecting(r containers.Rect) containers.ElemRanger[int]
+}
+
+func randPoint(r *rand.Rand) containers.Point {
+	return containers.Point{r.Float64() * 100, r.Float64() * 100}
+}
+
+func randRect(r *rand.Rand) containers.Rect {
+	p := randPoint(r)
+	return containers.Rect{Min: p, Max: containers.Point{p[0] + r.Float64()*10, p[1] + r.Float64()*10}}
+}
+
+// dist returns the distance from p to the nearest point of r.
+func dist(r containers.Rect, p containers.Point) float64 {
+	sum := 0.0
+	for i := range p {
+		d := max(r.Min[i]-p[i], p[i]-r.Max[i], 0)
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func sortedElems(r containers.ElemRanger[int]) []int {
+	var ids []int
+	r.RangeElems(func(id int) bool {
+		ids = append(ids, id)
+		return true
+	})
+	slices.Sort(ids)
+	return ids
+}
+
+// checkSpatial compares the results of queries on idx against a linear scan
+// of the live entries of rects, keyed by value.
+func checkSpatial(t *testing.T, r *rand.Rand, idx spatialIndex, rects map[int]containers.Rect) {
+	t.Helper()
+
+	var all []int
+	for id := range rects {
+		all = append(all, id)
+	}
+	slices.Sort(all)
+	if idx.Len() != len(all) {
+		t.Fatalf("Len() = %d; want %d", idx.Len(), len(all))
+	}
+	if got := sortedElems(idx); !slices.Equal(got, all) {
+		t.Fatalf("RangeElems visited %v; want %v", got, all)
+	}
+
+	for i := 0; i < 20; i++ {
+		q := randRect(r)
+		var want []int
+		for _, id := range all {
+			if rects[id].Intersects(q) {
+				want = append(want, id)
+			}
+		}
+		if got := sortedElems(idx.Intersecting(q)); !slices.Equal(got, want) {
+			t.Fatalf("Intersecting(%v) = %v; want %v", q, got, want)
+		}
+
+		p, radius := randPoint(r), r.Float64()*20
+		want = want[:0]
+		for _, id := range all {
+			if dist(rects[id], p) <= radius {
+				want = append(want, id)
+			}
+		}
+		if got := sortedElems(idx.WithinRadius(p, radius)); !slices.Equal(got, want) {
+			t.Fatalf("WithinRadius(%v, %v) = %v; want %v", p, radius, got, want)
+		}
+
+		k := r.Intn(10)
+		byDist := slices.Clone(all)
+		slices.SortFunc(byDist, func(a, b int) int {
+			return cmpFloat(dist(rects[a], p), dist(rects[b], p))
+		})
+		got := idx.Nearest(p, k)
+		if len(got) != min(k, len(all)) {
+			t.Fatalf("Nearest(%v, %d) returned %d values; want %d", p, k, len(got), min(k, len(all)))
+		}
+		for j, id := range got {
+			if d, want := dist(rects[id], p), dist(rects[byDist[j]], p); d != want {
+				t.Fatalf("Nearest(%v, %d)[%d] is at distance %v; want %v", p, k, j, d, want)
+			}
+		}
+	}
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func TestKDTree(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := make(map[int]containers.Rect)
+
+	var ps []containers.Point
+	var ids []int
+	for id := 0; id < 300; id++ {
+		p := randPoint(r)
+		ps = append(ps, p)
+		ids = append(ids, id)
+		points[id] = containers.PointRect(p)
+	}
+	kd := containers.NewKDTree[int](2)
+	kd.Load(ps, ids)
+	checkSpatial(t, r, kd, points)
+
+	for id := 300; id < 600; id++ {
+		p := randPoint(r)
+		kd.Insert(p, id)
+		points[id] = containers.PointRect(p)
+	}
+	checkSpatial(t, r, kd, points)
+
+	// Delete enough points to force the tree to rebuild.
+	for _, id := range r.Perm(600)[:450] {
+		if !kd.Delete(points[id].Min, func(v int) bool { return v == id }) {
+			t.Fatalf("Delete(%d) = false; want true", id)
+		}
+		delete(points, id)
+		if len(points)%50 == 0 {
+			checkSpatial(t, r, kd, points)
+		}
+	}
+	if kd.Delete(randPoint(r), nil) {
+		t.Errorf("Delete of an absent point = true; want false")
+	}
+}
+
+func TestRTree(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	rects := make(map[int]containers.Rect)
+
+	var rs []containers.Rect
+	var ids []int
+	for id := 0; id < 300; id++ {
+		rect := randRect(r)
+		rs = append(rs, rect)
+		ids = append(ids, id)
+		rects[id] = rect
+	}
+	rt := containers.NewRTree[int](2)
+	rt.Load(rs, ids)
+	checkSpatial(t, r, rt, rects)
+
+	// Enough inserts to split nodes at several levels.
+	for id := 300; id < 2000; id++ {
+		rect := randRect(r)
+		rt.Insert(rect, id)
+		rects[id] = rect
+	}
+	checkSpatial(t, r, rt, rects)
+}
+
+func TestRTreeDeleteHeavy(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	rects := make(map[int]containers.Rect)
+	rt := containers.NewRTree[int](2)
+	for id := 0; id < 2000; id++ {
+		rect := randRect(r)
+		rt.Insert(rect, id)
+		rects[id] = rect
+	}
+
+	// Deleting most entries underflows nodes throughout the tree,
+	// condensing it and reinserting the orphaned entries.
+	for i, id := range r.Perm(2000) {
+		if !rt.Delete(rects[id], func(v int) bool { return v == id }) {
+			t.Fatalf("Delete(%d) = false; want true", id)
+		}
+		if rt.Delete(rects[id], func(v int) bool { return v == id }) {
+			t.Fatalf("second Delete(%d) = true; want false", id)
+		}
+		delete(rects, id)
+		if i%100 == 0 || len(rects) < 20 {
+			checkSpatial(t, r, rt, rects)
+		}
+	}
+	if rt.Len() != 0 {
+		t.Fatalf("Len() = %d after deleting everything", rt.Len())
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"unsafe"
+)
+
+// A RowID identifies a row of a Table.
+type RowID uint64
+
+// A Table is a collection of records of type T with any number of secondary
+// indexes, which are kept consistent as rows are inserted, updated, and
+// deleted. Indexes are declared with AddUniqueIndex, AddIndex, and
+// AddOrderedIndex.
+//
+// A Table is not safe for concurrent use.
+type Table[T any] struct {
+	rows    map[RowID]T
+	next    RowID
+	indexes []tableIndex[T]
+}
+
+// A tableIndex is the Table's view of one of its indexes.
+type tableIndex[T any] interface {
+	// check reports an error if inserting x as row id would violate a
+	// constraint of the index, ignoring any conflict with id itself.
+	check(id RowID, x T) error
+	insert(id RowID, x T)
+	remove(id RowID, x T)
+}
+
+// NewTable returns a new, empty Table.
+func NewTable[T any]() *Table[T] {
+	return &Table[T]{rows: make(map[RowID]T)}
+}
+
+func (t *Table[T]) Len() int         { return len(t.rows) }
+func (t *Table[T]) SizeBytes() int64 { return referencedSize(t) }
+
+// Index returns the row with the given ID.
+func (t *Table[T]) Index(id RowID) (T, bool) {
+	x, ok := t.rows[id]
+	return x, ok
+}
+
+func (t *Table[T]) check(id RowID, x T) error {
+	for _, ix := range t.indexes {
+		if err := ix.check(id, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Insert adds x to the table and returns its ID.
+// If x would violate a unique index, Insert returns an error and
+// the table is unchanged.
+func (t *Table[T]) Insert(x T) (RowID, error) {
+	id := t.next + 1
+	if err := t.check(id, x); err != nil {
+		return 0, err
+	}
+	t.next = id
+	t.rows[id] = x
+	for _, ix := range t.indexes {
+		ix.insert(id, x)
+	}
+	return id, nil
+}
+
+// Update replaces the row with the given ID by x.
+// If there is no such row, or x would violate a unique index, Update returns
+// an error and the table is unchanged.
+func (t *Table[T]) Update(id RowID, x T) error {
+	old, ok := t.rows[id]
+	if !ok {
+		return fmt.Errorf("Table.Update: no row with ID %d", id)
+	}
+	if err := t.check(id, x); err != nil {
+		return err
+	}
+	for _, ix := range t.indexes {
+		ix.remove(id, old)
+		ix.insert(id, x)
+	}
+	t.rows[id] = x
+	return nil
+}
+
+// Delete removes the row with the given ID, if present.
+func (t *Table[T]) Delete(id RowID) {
+	old, ok := t.rows[id]
+	if !ok {
+		return
+	}
+	for _, ix := range t.indexes {
+		ix.remove(id, old)
+	}
+	delete(t.rows, id)
+}
+
+func (t *Table[T]) RangeKeys(f func(RowID) bool) {
+	for id := range t.rows {
+		if !f(id) {
+			break
+		}
+	}
+}
+
+func (t *Table[T]) RangeElems(f func(T) bool) {
+	for _, x := range t.rows {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+func (t *Table[T]) Range(f func(RowID, T) bool) {
+	for id, x := range t.rows {
+		if !f(id, x) {
+			break
+		}
+	}
+}
+
+// addIndex populates ix with the existing rows of t and registers it.
+func addIndex[T any](t *Table[T], ix tableIndex[T]) error {
+	for id, x := range t.rows {
+		if err := ix.check(id, x); err != nil {
+			return err
+		}
+		ix.insert(id, x)
+	}
+	t.indexes = append(t.indexes, ix)
+	return nil
+}
+
+// A UniqueIndex maps each key to at most one row of a Table.
+type UniqueIndex[T any, K comparable] struct {
+	t   *Table[T]
+	key func(T) K
+	ids map[K]RowID
+}
+
+// AddUniqueIndex adds to t an index on the key extracted by key,
+// which must be unique across all rows.
+// If the existing rows of t have duplicate keys, AddUniqueIndex returns an error.
+func AddUniqueIndex[T any, K comparable](t *Table[T], key func(T) K) (*UniqueIndex[T, K], error) {
+	ix := &UniqueIndex[T, K]{t: t, key: key, ids: make(map[K]RowID)}
+	if err := addIndex[T](t, ix); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+func (ix *UniqueIndex[T, K]) check(id RowID, x T) error {
+	k := ix.key(x)
+	if other, ok := ix.ids[k]; ok && other != id {
+		return fmt.Errorf("Table: duplicate key %v in unique index (row %d)", k, other)
+	}
+	return nil
+}
+
+func (ix *UniqueIndex[T, K]) insert(id RowID, x T) { ix.ids[ix.key(x)] = id }
+func (ix *UniqueIndex[T, K]) remove(id RowID, x T) { delete(ix.ids, ix.key(x)) }
+
+func (ix *UniqueIndex[T, K]) Len() int { return len(ix.ids) }
+
+// SizeBytes reports the memory used by the index, not counting its Table.
+func (ix *UniqueIndex[T, K]) SizeBytes() int64 {
+	return int64(unsafe.Sizeof(*ix)) + referencedSize(ix.ids)
+}
+
+// Index returns the row with key k.
+func (ix *UniqueIndex[T, K]) Index(k K) (T, bool) {
+	id, ok := ix.ids[k]
+	if !ok {
+		return *new(T), false
+	}
+	return ix.t.rows[id], true
+}
+
+// ID returns the ID of the row with key k.
+func (ix *UniqueIndex[T, K]) ID(k K) (RowID, bool) {
+	id, ok := ix.ids[k]
+	return id, ok
+}
+
+// A MultiIndex maps each key to any number of rows of a Table.
+type MultiIndex[T any, K comparable] struct {
+	t   *Table[T]
+	key func(T) K
+	ids map[K]map[RowID]struct{}
+}
+
+// AddIndex adds to t a non-unique index on the key extracted by key.
+func AddIndex[T any, K comparable](t *Table[T], key func(T) K) *MultiIndex[T, K] {
+	ix := &MultiIndex[T, K]{t: t, key: key, ids: make(map[K]map[RowID]struct{})}
+	addIndex[T](t, ix) // A non-unique index cannot fail.
+	return ix
+}
+
+func (ix *MultiIndex[T, K]) check(RowID, T) error { return nil }
+
+func (ix *MultiIndex[T, K]) insert(id RowID, x T) {
+	k := ix.key(x)
+	s := ix.ids[k]
+	if s == nil {
+		s = make(map[RowID]struct{})
+		ix.ids[k] = s
+	}
+	s[id] = struct{}{}
+}
+
+func (ix *MultiIndex[T, K]) remove(id RowID, x T) {
+	k := ix.key(x)
+	delete(ix.ids[k], id)
+	if len(ix.ids[k]) == 0 {
+		delete(ix.ids, k)
+	}
+}
+
+// Len returns the number of distinct keys in the index.
+func (ix *MultiIndex[T, K]) Len() int { return len(ix.ids) }
+
+// SizeBytes reports the memory used by the index, not counting its Table.
+func (ix *MultiIndex[T, K]) SizeBytes() int64 {
+	return int64(unsafe.Sizeof(*ix)) + referencedSize(ix.ids)
+}
+
+// Index returns the rows with key k, in arbitrary order.
+func (ix *MultiIndex[T, K]) Index(k K) ([]T, bool) {
+	s, ok := ix.ids[k]
+	if !ok {
+		return nil, false
+	}
+	xs := make([]T, 0, len(s))
+	for id := range s {
+		xs = append(xs, ix.t.rows[id])
+	}
+	return xs, true
+}
+
+// IDs returns the IDs of the rows with key k, in arbitrary order.
+func (ix *MultiIndex[T, K]) IDs(k K) []RowID {
+	ids := make([]RowID, 0, len(ix.ids[k]))
+	for id := range ix.ids[k] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// An OrderedIndex is a non-unique index whose keys are kept in sorted order,
+// supporting range scans.
+//
+// Entries are stored in a sorted slice, so inserts and deletes take time
+// linear in the size of the index.
+type OrderedIndex[T any, K cmp.Ordered] struct {
+	t       *Table[T]
+	key     func(T) K
+	entries []orderedEntry[K]
+}
+
+type orderedEntry[K cmp.Ordered] struct {
+	key K
+	id  RowID
+}
+
+func compareOrderedEntries[K cmp.Ordered](a, b orderedEntry[K]) int {
+	if c := cmp.Compare(a.key, b.key); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.id, b.id)
+}
+
+// AddOrderedIndex adds to t a non-unique, ordered index on the key extracted
+// by key.
+func AddOrderedIndex[T any, K cmp.Ordered](t *Table[T], key func(T) K) *OrderedIndex[T, K] {
+	ix := &OrderedIndex[T, K]{t: t, key: key}
+	addIndex[T](t, ix) // A non-unique index cannot fail.
+	return ix
+}
+
+func (ix *OrderedIndex[T, K]) check(RowID, T) error { return nil }
+
+func (ix *OrderedIndex[T, K]) insert(id RowID, x T) {
+	e := orderedEntry[K]{key: ix.key(x), id: id}
+	i, _ := slices.BinarySearchFunc(ix.entries, e, compareOrderedEntries[K])
+	ix.entries = slices.Insert(ix.entries, i, e)
+}
+
+func (ix *OrderedIndex[T, K]) remove(id RowID, x T) {
+	e := orderedEntry[K]{key: ix.key(x), id: id}
+	if i, ok := slices.BinarySearchFunc(ix.entries, e, compareOrderedEntries[K]); ok {
+		ix.entries = slices.Delete(ix.entries, i, i+1)
+	}
+}
+
+// Len returns the number of rows in the index.
+func (ix *OrderedIndex[T, K]) Len() int { return len(ix.entries) }
+
+// SizeBytes reports the memory used by the index, not counting its Table.
+func (ix *OrderedIndex[T, K]) SizeBytes() int64 {
+	return int64(unsafe.Sizeof(*ix)) + referencedSize(ix.entries)
+}
+
+// lowerBound returns the position of the first entry with key >= k.
+func (ix *OrderedIndex[T, K]) lowerBound(k K) int {
+	i, _ := slices.BinarySearchFunc(ix.entries, k, func(e orderedEntry[K], k K) int {
+		return cmp.Compare(e.key, k)
+	})
+	return i
+}
+
+// Index returns the rows with key k, in RowID order.
+func (ix *OrderedIndex[T, K]) Index(k K) ([]T, bool) {
+	var xs []T
+	for i := ix.lowerBound(k); i < len(ix.entries) && ix.entries[i].key == k; i++ {
+		xs = append(xs, ix.t.rows[ix.entries[i].id])
+	}
+	return xs, len(xs) > 0
+}
+
+// RangeBetween calls f for each row with a key in the half-open interval
+// [lo, hi), in key order.
+func (ix *OrderedIndex[T, K]) RangeBetween(lo, hi K, f func(K, T) bool) {
+	for i := ix.lowerBound(lo); i < len(ix.entries) && ix.entries[i].key < hi; i++ {
+		e := ix.entries[i]
+		if !f(e.key, ix.t.rows[e.id]) {
+			break
+		}
+	}
+}
+
+// Range calls f for each row in key order.
+func (ix *OrderedIndex[T, K]) Range(f func(K, T) bool) {
+	for _, e := range ix.entries {
+		if !f(e.key, ix.t.rows[e.id]) {
+			break
+		}
+	}
+}
+
+func (ix *OrderedIndex[T, K]) RangeKeys(f func(K) bool) {
+	for _, e := range ix.entries {
+		if !f(e.key) {
+			break
+		}
+	}
+}
+
+func (ix *OrderedIndex[T, K]) RangeElems(f func(T) bool) {
+	for _, e := range ix.entries {
+		if !f(ix.t.rows[e.id]) {
+			break
+		}
+	}
+}
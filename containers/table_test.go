@@ -0,0 +1,212 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+type employee struct {
+	email string
+	dept  string
+	age   int
+}
+
+var (
+	_ containers.Lenner                             = (*containers.Table[employee])(nil)
+	_ containers.Ranger[containers.RowID, employee] = (*containers.Table[employee])(nil)
+	_ containers.Indexer[string, employee]          = (*containers.UniqueIndex[employee, string])(nil)
+	_ containers.Indexer[string, []employee]        = (*containers.MultiIndex[employee, string])(nil)
+	_ containers.Indexer[int, []employee]           = (*containers.OrderedIndex[employee, int])(nil)
+)
+
+func emails(xs []employee) []string {
+	s := make([]string, 0, len(xs))
+	for _, x := range xs {
+		s = append(s, x.email)
+	}
+	return s
+}
+
+func TestTable(t *testing.T) {
+	tbl := containers.NewTable[employee]()
+	alice, _ := tbl.Insert(employee{"alice@example.com", "eng", 30})
+	bob, _ := tbl.Insert(employee{"bob@example.com", "eng", 40})
+	carol, _ := tbl.Insert(employee{"carol@example.com", "ops", 30})
+
+	// Indexes added after rows are inserted are populated from the table.
+	byEmail, err := containers.AddUniqueIndex(tbl, func(e employee) string { return e.email })
+	if err != nil {
+		t.Fatal(err)
+	}
+	byDept := containers.AddIndex(tbl, func(e employee) string { return e.dept })
+	byAge := containers.AddOrderedIndex(tbl, func(e employee) int { return e.age })
+
+	if tbl.Len() != 3 || byEmail.Len() != 3 || byDept.Len() != 2 || byAge.Len() != 3 {
+		t.Fatalf("Len() of table and indexes = %d, %d, %d, %d; want 3, 3, 2, 3",
+			tbl.Len(), byEmail.Len(), byDept.Len(), byAge.Len())
+	}
+
+	if x, ok := byEmail.Index("bob@example.com"); !ok || x.age != 40 {
+		t.Errorf(`byEmail.Index("bob@example.com") = %v, %v; want age 40`, x, ok)
+	}
+	if id, ok := byEmail.ID("carol@example.com"); !ok || id != carol {
+		t.Errorf(`byEmail.ID("carol@example.com") = %d, %v; want %d, true`, id, ok, carol)
+	}
+
+	eng, _ := byDept.Index("eng")
+	got := emails(eng)
+	slices.Sort(got)
+	if want := []string{"alice@example.com", "bob@example.com"}; !slices.Equal(got, want) {
+		t.Errorf(`byDept.Index("eng") = %v; want %v`, got, want)
+	}
+	ids := byDept.IDs("eng")
+	slices.Sort(ids)
+	if want := []containers.RowID{alice, bob}; !slices.Equal(ids, want) {
+		t.Errorf(`byDept.IDs("eng") = %v; want %v`, ids, want)
+	}
+
+	// Inserting a row updates every index.
+	dave, err := tbl.Insert(employee{"dave@example.com", "ops", 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := byEmail.ID("dave@example.com"); id != dave {
+		t.Errorf(`byEmail.ID("dave@example.com") = %d; want %d`, id, dave)
+	}
+	var ages []int
+	byAge.RangeKeys(func(age int) bool {
+		ages = append(ages, age)
+		return true
+	})
+	if want := []int{25, 30, 30, 40}; !slices.Equal(ages, want) {
+		t.Errorf("byAge keys = %v; want %v", ages, want)
+	}
+
+	// Deleting a row removes it from every index.
+	tbl.Delete(bob)
+	tbl.Delete(bob)
+	if _, ok := tbl.Index(bob); ok {
+		t.Errorf("Index(bob) after Delete reported ok")
+	}
+	if _, ok := byEmail.Index("bob@example.com"); ok {
+		t.Errorf(`byEmail.Index("bob@example.com") after Delete reported ok`)
+	}
+	if ids := byDept.IDs("eng"); !slices.Equal(ids, []containers.RowID{alice}) {
+		t.Errorf(`byDept.IDs("eng") after Delete = %v; want [%d]`, ids, alice)
+	}
+	if _, ok := byAge.Index(40); ok {
+		t.Errorf("byAge.Index(40) after Delete reported ok")
+	}
+	if tbl.Len() != 3 || byEmail.Len() != 3 || byDept.Len() != 2 || byAge.Len() != 3 {
+		t.Errorf("Len() of table and indexes after Delete = %d, %d, %d, %d; want 3, 3, 2, 3",
+			tbl.Len(), byEmail.Len(), byDept.Len(), byAge.Len())
+	}
+}
+
+func TestTableUniqueViolation(t *testing.T) {
+	tbl := containers.NewTable[employee]()
+	byEmail, _ := containers.AddUniqueIndex(tbl, func(e employee) string { return e.email })
+	byDept := containers.AddIndex(tbl, func(e employee) string { return e.dept })
+	byAge := containers.AddOrderedIndex(tbl, func(e employee) int { return e.age })
+
+	alice, _ := tbl.Insert(employee{"alice@example.com", "eng", 30})
+	bob, _ := tbl.Insert(employee{"bob@example.com", "ops", 40})
+
+	if _, err := tbl.Insert(employee{"alice@example.com", "sales", 50}); err == nil {
+		t.Errorf("Insert of duplicate email succeeded")
+	}
+
+	// A failed Update leaves the row and all of its index entries unchanged,
+	// including those of indexes that could have accepted the new row.
+	if err := tbl.Update(bob, employee{"alice@example.com", "sales", 50}); err == nil {
+		t.Errorf("Update to duplicate email succeeded")
+	}
+	if x, _ := tbl.Index(bob); x != (employee{"bob@example.com", "ops", 40}) {
+		t.Errorf("Index(bob) after failed Update = %v", x)
+	}
+	if id, _ := byEmail.ID("alice@example.com"); id != alice {
+		t.Errorf(`byEmail.ID("alice@example.com") after failed Update = %d; want %d`, id, alice)
+	}
+	if id, _ := byEmail.ID("bob@example.com"); id != bob {
+		t.Errorf(`byEmail.ID("bob@example.com") after failed Update = %d; want %d`, id, bob)
+	}
+	if _, ok := byDept.Index("sales"); ok {
+		t.Errorf(`byDept.Index("sales") after failed Update reported ok`)
+	}
+	if _, ok := byAge.Index(50); ok {
+		t.Errorf("byAge.Index(50) after failed Update reported ok")
+	}
+	if tbl.Len() != 2 || byEmail.Len() != 2 || byDept.Len() != 2 || byAge.Len() != 2 {
+		t.Errorf("Len() of table and indexes = %d, %d, %d, %d; want 2 each",
+			tbl.Len(), byEmail.Len(), byDept.Len(), byAge.Len())
+	}
+
+	// An Update that keeps a row's own unique key succeeds.
+	if err := tbl.Update(bob, employee{"bob@example.com", "sales", 41}); err != nil {
+		t.Errorf("Update keeping the same email: %v", err)
+	}
+	if xs, _ := byDept.Index("sales"); !slices.Equal(emails(xs), []string{"bob@example.com"}) {
+		t.Errorf(`byDept.Index("sales") = %v; want [bob@example.com]`, emails(xs))
+	}
+	if _, ok := byDept.Index("ops"); ok {
+		t.Errorf(`byDept.Index("ops") after moving bob reported ok`)
+	}
+	if err := tbl.Update(containers.RowID(99), employee{}); err == nil {
+		t.Errorf("Update of missing row succeeded")
+	}
+
+	// A unique index cannot be added over existing duplicates.
+	if _, err := containers.AddUniqueIndex(tbl, func(e employee) int { return e.age / 100 }); err == nil {
+		t.Errorf("AddUniqueIndex over duplicate keys succeeded")
+	}
+}
+
+func TestOrderedIndex(t *testing.T) {
+	tbl := containers.NewTable[employee]()
+	byAge := containers.AddOrderedIndex(tbl, func(e employee) int { return e.age })
+
+	var want30 []string
+	for i, age := range []int{30, 20, 30, 40, 30, 10} {
+		e := employee{email: string(rune('a'+i)) + "@example.com", age: age}
+		tbl.Insert(e)
+		if age == 30 {
+			want30 = append(want30, e.email)
+		}
+	}
+
+	// Rows with equal keys are returned in RowID order.
+	xs, ok := byAge.Index(30)
+	if !ok || !slices.Equal(emails(xs), want30) {
+		t.Errorf("Index(30) = %v, %v; want %v, true", emails(xs), ok, want30)
+	}
+	if xs, ok := byAge.Index(25); ok {
+		t.Errorf("Index(25) = %v, true; want false", emails(xs))
+	}
+
+	var got []int
+	byAge.RangeBetween(20, 40, func(age int, e employee) bool {
+		if e.age != age {
+			t.Errorf("RangeBetween: key %d for row with age %d", age, e.age)
+		}
+		got = append(got, age)
+		return true
+	})
+	if want := []int{20, 30, 30, 30}; !slices.Equal(got, want) {
+		t.Errorf("RangeBetween(20, 40) = %v; want %v", got, want)
+	}
+
+	got = nil
+	byAge.Range(func(age int, _ employee) bool {
+		got = append(got, age)
+		return len(got) < 3
+	})
+	if want := []int{10, 20, 30}; !slices.Equal(got, want) {
+		t.Errorf("Range stopped early = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// TTLMapOptions configures a TTLMap.
+type TTLMapOptions[K comparable, V any] struct {
+	// Clock is the source of time for expiry. If nil, SystemClock is used.
+	Clock Clock
+
+	// OnExpire, if non-nil, is called for each entry that expires,
+	// without any locks held. It is not called for entries that are
+	// overwritten or deleted explicitly.
+	OnExpire func(K, V)
+
+	// SweepInterval, if positive, starts a background goroutine that removes
+	// expired entries at that interval until the TTLMap is closed.
+	// Otherwise, entries expire lazily as they are accessed
+	// and whenever Sweep is called.
+	SweepInterval time.Duration
+}
+
+// A TTLMap is a map whose entries expire after a time-to-live.
+// Index and the Range methods never observe expired entries.
+// A TTLMap is safe for concurrent use by multiple goroutines.
+type TTLMap[K comparable, V any] struct {
+	ttl      time.Duration
+	clock    Clock
+	onExpire func(K, V)
+
+	mu sync.Mutex
+	m  map[K]ttlEntry[V]
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+type ttlEntry[V any] struct {
+	v       V
+	expires time.Time
+}
+
+// NewTTLMap returns an empty TTLMap whose entries expire, by default,
+// ttl after they are set. If opts is nil, default options are used.
+func NewTTLMap[K comparable, V any](ttl time.Duration, opts *TTLMapOptions[K, V]) *TTLMap[K, V] {
+	if ttl <= 0 {
+		panic(fmt.Sprintf("NewTTLMap: nonpositive TTL %v", ttl))
+	}
+	m := &TTLMap[K, V]{
+		ttl:   ttl,
+		clock: SystemClock,
+		m:     make(map[K]ttlEntry[V]),
+	}
+	if opts != nil {
+		if opts.Clock != nil {
+			m.clock = opts.Clock
+		}
+		m.onExpire = opts.OnExpire
+		if opts.SweepInterval > 0 {
+			m.stop = make(chan struct{})
+			m.done = make(chan struct{})
+			go m.sweeper(opts.SweepInterval)
+		}
+	}
+	return m
+}
+
+func (m *TTLMap[K, V]) sweeper(interval time.Duration) {
+	defer close(m.done)
+	tick := make(chan struct{}, 1)
+	timer := m.clock.AfterFunc(interval, func() { tick <- struct{}{} })
+	for {
+		select {
+		case <-m.stop:
+			timer.Stop()
+			return
+		case <-tick:
+			m.Sweep()
+			timer.Reset(interval)
+		}
+	}
+}
+
+// Close stops the background sweeper, if any, and waits for it to exit.
+// The TTLMap remains usable, with lazy expiry, after Close.
+func (m *TTLMap[K, V]) Close() {
+	if m.stop == nil {
+		return
+	}
+	m.closeOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+// expire calls the OnExpire callback for each expired entry.
+func (m *TTLMap[K, V]) expire(keys []K, vals []V) {
+	if m.onExpire == nil {
+		return
+	}
+	for i, k := range keys {
+		m.onExpire(k, vals[i])
+	}
+}
+
+// Sweep removes all expired entries from m.
+func (m *TTLMap[K, V]) Sweep() {
+	now := m.clock.Now()
+	var keys []K
+	var vals []V
+	m.mu.Lock()
+	for k, e := range m.m {
+		if !now.Before(e.expires) {
+			delete(m.m, k)
+			keys = append(keys, k)
+			vals = append(vals, e.v)
+		}
+	}
+	m.mu.Unlock()
+	m.expire(keys, vals)
+}
+
+// Len returns the number of unexpired entries in m.
+// It does not remove expired entries, but it takes time linear in the number
+// of entries, including expired entries that have not yet been removed.
+func (m *TTLMap[K, V]) Len() int {
+	now := m.clock.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, e := range m.m {
+		if now.Before(e.expires) {
+			n++
+		}
+	}
+	return n
+}
+
+// SizeBytes reports the memory used by m, including expired entries that
+// have not yet been removed, but not its Clock.
+func (m *TTLMap[K, V]) SizeBytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(unsafe.Sizeof(*m)) + referencedSize(m.m)
+}
+
+func (m *TTLMap[K, V]) Index(k K) (V, bool) {
+	now := m.clock.Now()
+	m.mu.Lock()
+	e, ok := m.m[k]
+	if ok && !now.Before(e.expires) {
+		delete(m.m, k)
+		m.mu.Unlock()
+		m.expire([]K{k}, []V{e.v})
+		return *new(V), false
+	}
+	m.mu.Unlock()
+	return e.v, ok
+}
+
+// SetIndex sets the value for k, expiring after the default TTL.
+func (m *TTLMap[K, V]) SetIndex(k K, v V) {
+	m.SetWithTTL(k, v, m.ttl)
+}
+
+// SetWithTTL sets the value for k, expiring after ttl.
+func (m *TTLMap[K, V]) SetWithTTL(k K, v V, ttl time.Duration) {
+	expires := m.clock.Now().Add(ttl)
+	m.mu.Lock()
+	m.m[k] = ttlEntry[V]{v: v, expires: expires}
+	m.mu.Unlock()
+}
+
+// Expiry returns the time at which the entry for k expires,
+// and whether the entry is present and unexpired.
+func (m *TTLMap[K, V]) Expiry(k K) (time.Time, bool) {
+	now := m.clock.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.m[k]
+	if !ok || !now.Before(e.expires) {
+		return time.Time{}, false
+	}
+	return e.expires, true
+}
+
+func (m *TTLMap[K, V]) Delete(k K) {
+	m.mu.Lock()
+	delete(m.m, k)
+	m.mu.Unlock()
+}
+
+// snapshot returns the unexpired entries of m.
+func (m *TTLMap[K, V]) snapshot() ([]K, []V) {
+	now := m.clock.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]K, 0, len(m.m))
+	vals := make([]V, 0, len(m.m))
+	for k, e := range m.m {
+		if now.Before(e.expires) {
+			keys = append(keys, k)
+			vals = append(vals, e.v)
+		}
+	}
+	return keys, vals
+}
+
+// The Range methods iterate over a copy of the unexpired entries of m,
+// so f may safely call other methods on m. Making the copy takes time and
+// space linear in the number of entries. Like Len, the Range methods do not
+// remove expired entries.
+
+func (m *TTLMap[K, V]) RangeKeys(f func(K) bool) {
+	keys, _ := m.snapshot()
+	for _, k := range keys {
+		if !f(k) {
+			break
+		}
+	}
+}
+
+func (m *TTLMap[K, V]) RangeElems(f func(V) bool) {
+	_, vals := m.snapshot()
+	for _, v := range vals {
+		if !f(v) {
+			break
+		}
+	}
+}
+
+func (m *TTLMap[K, V]) Range(f func(K, V) bool) {
+	keys, vals := m.snapshot()
+	for i, k := range keys {
+		if !f(k, vals[i]) {
+			break
+		}
+	}
+}
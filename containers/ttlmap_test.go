@@ -0,0 +1,154 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// expiryLog records the entries passed to an OnExpire callback.
+type expiryLog struct {
+	mu      sync.Mutex
+	expired map[string]int
+}
+
+func (l *expiryLog) onExpire(k string, v int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.expired == nil {
+		l.expired = make(map[string]int)
+	}
+	l.expired[k] = v
+}
+
+func (l *expiryLog) get(k string) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	v, ok := l.expired[k]
+	return v, ok
+}
+
+func TestTTLMapLazyExpiry(t *testing.T) {
+	clock := containers.NewManualClock(time.Unix(0, 0))
+	var log expiryLog
+	m := containers.NewTTLMap[string, int](time.Minute, &containers.TTLMapOptions[string, int]{
+		Clock:    clock,
+		OnExpire: log.onExpire,
+	})
+
+	m.SetIndex("a", 1)
+	clock.Advance(30 * time.Second)
+	m.SetIndex("b", 2)
+
+	clock.Advance(30*time.Second - 1)
+	if v, ok := m.Index("a"); !ok || v != 1 {
+		t.Fatalf("Index(a) = %v, %v before expiry; want 1, true", v, ok)
+	}
+
+	clock.Advance(1)
+	if n := m.Len(); n != 1 {
+		t.Errorf("Len() = %d after a expired; want 1", n)
+	}
+	var keys []string
+	m.RangeKeys(func(k string) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if len(keys) != 1 || keys[0] != "b" {
+		t.Errorf("RangeKeys visited %q; want [b]", keys)
+	}
+	if _, ok := log.get("a"); ok {
+		t.Errorf("OnExpire(a) called before a was accessed or swept")
+	}
+
+	if v, ok := m.Index("a"); ok {
+		t.Errorf("Index(a) = %v, true after expiry; want false", v)
+	}
+	if v, ok := log.get("a"); !ok || v != 1 {
+		t.Errorf("OnExpire(a) recorded %v, %v; want 1, true", v, ok)
+	}
+
+	clock.Advance(30 * time.Second)
+	m.Sweep()
+	if v, ok := log.get("b"); !ok || v != 2 {
+		t.Errorf("OnExpire(b) recorded %v, %v after Sweep; want 2, true", v, ok)
+	}
+	if n := m.Len(); n != 0 {
+		t.Errorf("Len() = %d after everything expired; want 0", n)
+	}
+}
+
+func TestTTLMapSetWithTTL(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := containers.NewManualClock(start)
+	var log expiryLog
+	m := containers.NewTTLMap[string, int](time.Minute, &containers.TTLMapOptions[string, int]{
+		Clock:    clock,
+		OnExpire: log.onExpire,
+	})
+
+	m.SetWithTTL("long", 1, time.Hour)
+	m.SetIndex("short", 2)
+	if exp, ok := m.Expiry("long"); !ok || !exp.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expiry(long) = %v, %v; want %v, true", exp, ok, start.Add(time.Hour))
+	}
+	if exp, ok := m.Expiry("short"); !ok || !exp.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expiry(short) = %v, %v; want %v, true", exp, ok, start.Add(time.Minute))
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := m.Expiry("short"); ok {
+		t.Errorf("Expiry(short) reported present after its TTL")
+	}
+	if v, ok := m.Index("long"); !ok || v != 1 {
+		t.Errorf("Index(long) = %v, %v; want 1, true", v, ok)
+	}
+
+	// Overwriting or deleting an entry does not report it as expired.
+	m.SetIndex("long", 3)
+	m.Delete("long")
+	clock.Advance(time.Hour)
+	m.Sweep()
+	if _, ok := log.get("long"); ok {
+		t.Errorf("OnExpire called for a deleted entry")
+	}
+}
+
+func TestTTLMapSweeper(t *testing.T) {
+	clock := containers.NewManualClock(time.Unix(0, 0))
+	expired := make(chan string, 1)
+	m := containers.NewTTLMap[string, int](time.Minute, &containers.TTLMapOptions[string, int]{
+		Clock:         clock,
+		OnExpire:      func(k string, _ int) { expired <- k },
+		SweepInterval: time.Second,
+	})
+	m.SetIndex("a", 1)
+
+	for i := 0; i < 60; i++ {
+		for clock.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Second)
+	}
+	if k := <-expired; k != "a" {
+		t.Errorf("sweeper expired %q; want a", k)
+	}
+
+	m.Close()
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Waiters() = %d after Close; want 0", n)
+	}
+	m.SetIndex("b", 2)
+	clock.Advance(2 * time.Minute)
+	select {
+	case k := <-expired:
+		t.Errorf("sweeper expired %q after Close", k)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
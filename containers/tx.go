@@ -0,0 +1,255 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrConflict is returned by Tx.Commit if another transaction committed a
+// conflicting change after the transaction began.
+var ErrConflict = errors.New("transaction conflict")
+
+// ErrTxDone is returned by Tx.Commit if the transaction has already been
+// committed or rolled back.
+var ErrTxDone = errors.New("transaction already committed or rolled back")
+
+// A Transactional wraps an IndexSetter to support multi-key transactions
+// with optimistic concurrency control.
+//
+// All access to the underlying container must go through the Transactional
+// (or its transactions) for commits to be atomic and conflicts detected.
+// A Transactional is safe for concurrent use by multiple goroutines, even if
+// the underlying container is not.
+type Transactional[K comparable, V any] struct {
+	mu      sync.Mutex
+	base    IndexSetter[K, V]
+	version uint64 // incremented by each commit
+
+	// versions records the version of the last commit to write each key,
+	// for keys written since the oldest open transaction began.
+	versions map[K]uint64
+	pruneAt  int            // size of versions at which to next prune it
+	open     map[uint64]int // number of open transactions by start version
+}
+
+// NewTransactional returns a Transactional wrapping base.
+// To support deletion, base must also implement Deleter[K].
+func NewTransactional[K comparable, V any](base IndexSetter[K, V]) *Transactional[K, V] {
+	return &Transactional[K, V]{
+		base:     base,
+		versions: make(map[K]uint64),
+		open:     make(map[uint64]int),
+	}
+}
+
+// written records that k was written by the commit with the current version.
+// t.mu must be held.
+func (t *Transactional[K, V]) written(k K) {
+	if len(t.open) == 0 {
+		// No transaction can observe the write as a conflict.
+		return
+	}
+	t.versions[k] = t.version
+	if len(t.versions) > t.pruneAt {
+		t.prune()
+	}
+}
+
+// prune discards the versions that no open transaction can conflict with:
+// those no newer than the start of the oldest open transaction.
+// t.mu must be held.
+func (t *Transactional[K, V]) prune() {
+	if len(t.open) == 0 {
+		clear(t.versions)
+	} else {
+		oldest := t.version
+		for start := range t.open {
+			oldest = min(oldest, start)
+		}
+		for k, v := range t.versions {
+			if v <= oldest {
+				delete(t.versions, k)
+			}
+		}
+	}
+	t.pruneAt = max(16, 2*len(t.versions))
+}
+
+// Index returns the committed value for k.
+func (t *Transactional[K, V]) Index(k K) (V, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.base.Index(k)
+}
+
+// SetIndex sets the value for k as a single-key transaction.
+func (t *Transactional[K, V]) SetIndex(k K, v V) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.version++
+	t.base.SetIndex(k, v)
+	t.written(k)
+}
+
+// Delete deletes k as a single-key transaction.
+// It panics if the underlying container does not implement Deleter[K].
+func (t *Transactional[K, V]) Delete(k K) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	del, ok := t.base.(Deleter[K])
+	if !ok {
+		panic(fmt.Sprintf("Transactional.Delete: %T does not implement Deleter", t.base))
+	}
+	t.version++
+	del.Delete(k)
+	t.written(k)
+}
+
+// SizeBytes reports the memory used by t, including its underlying container.
+func (t *Transactional[K, V]) SizeBytes() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return referencedSize(t)
+}
+
+// Begin starts a new transaction.
+// The transaction must be finished by calling Commit or Rollback: until then,
+// t retains the versions of the keys written since it began.
+func (t *Transactional[K, V]) Begin() *Tx[K, V] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.open[t.version]++
+	return &Tx[K, V]{
+		t:      t,
+		start:  t.version,
+		reads:  make(map[K]struct{}),
+		writes: make(map[K]txWrite[V]),
+	}
+}
+
+// A Tx is a transaction on a Transactional.
+//
+// A Tx buffers its writes until Commit, and its reads observe its own writes.
+// A Tx is not safe for concurrent use.
+type Tx[K comparable, V any] struct {
+	t      *Transactional[K, V]
+	start  uint64
+	reads  map[K]struct{}
+	writes map[K]txWrite[V]
+	done   bool
+}
+
+type txWrite[V any] struct {
+	v       V
+	deleted bool
+}
+
+// finish marks tx as done and unregisters it from its Transactional.
+// tx.t.mu must be held.
+func (tx *Tx[K, V]) finish() {
+	tx.done = true
+	t := tx.t
+	if t.open[tx.start]--; t.open[tx.start] == 0 {
+		delete(t.open, tx.start)
+	}
+	if len(t.open) == 0 || len(t.versions) > t.pruneAt {
+		t.prune()
+	}
+}
+
+func (tx *Tx[K, V]) checkDone(op string) {
+	if tx.done {
+		panic("Tx." + op + ": " + ErrTxDone.Error())
+	}
+}
+
+// Index returns the value for k as seen by the transaction.
+// It panics if tx has been committed or rolled back.
+func (tx *Tx[K, V]) Index(k K) (V, bool) {
+	tx.checkDone("Index")
+	if w, ok := tx.writes[k]; ok {
+		return w.v, !w.deleted
+	}
+	tx.reads[k] = struct{}{}
+	return tx.t.Index(k)
+}
+
+// SetIndex buffers a write of v to k.
+// It panics if tx has been committed or rolled back.
+func (tx *Tx[K, V]) SetIndex(k K, v V) {
+	tx.checkDone("SetIndex")
+	tx.writes[k] = txWrite[V]{v: v}
+}
+
+// Delete buffers a deletion of k.
+// It panics if tx has been committed or rolled back.
+func (tx *Tx[K, V]) Delete(k K) {
+	tx.checkDone("Delete")
+	tx.writes[k] = txWrite[V]{deleted: true}
+}
+
+// Commit atomically applies the buffered writes of tx.
+//
+// If any key that tx read or wrote has been written by another commit since
+// tx began, Commit returns ErrConflict and applies nothing.
+// Either way, tx is finished, and a retry must use a new transaction.
+func (tx *Tx[K, V]) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	t := tx.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer tx.finish()
+
+	conflict := func(k K) bool { return t.versions[k] > tx.start }
+	for k := range tx.reads {
+		if conflict(k) {
+			return fmt.Errorf("%w: key %v", ErrConflict, k)
+		}
+	}
+	var del Deleter[K]
+	for k, w := range tx.writes {
+		if conflict(k) {
+			return fmt.Errorf("%w: key %v", ErrConflict, k)
+		}
+		if w.deleted && del == nil {
+			var ok bool
+			if del, ok = t.base.(Deleter[K]); !ok {
+				return fmt.Errorf("Tx.Commit: %T does not implement Deleter", t.base)
+			}
+		}
+	}
+
+	if len(tx.writes) == 0 {
+		return nil
+	}
+	t.version++
+	for k, w := range tx.writes {
+		if w.deleted {
+			del.Delete(k)
+		} else {
+			t.base.SetIndex(k, w.v)
+		}
+		t.written(k)
+	}
+	return nil
+}
+
+// Rollback discards the buffered writes of tx.
+// Rollback after Commit or Rollback has no effect.
+func (tx *Tx[K, V]) Rollback() {
+	if tx.done {
+		return
+	}
+	tx.t.mu.Lock()
+	defer tx.t.mu.Unlock()
+	tx.finish()
+	clear(tx.writes)
+}
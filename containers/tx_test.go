@@ -0,0 +1,140 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"errors"
+	"maps"
+	"strconv"
+	"testing"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+func TestTxConflict(t *testing.T) {
+	tr := containers.NewTransactional[string, int](containers.Map[string, int]{"n": 0})
+
+	a, b := tr.Begin(), tr.Begin()
+	for _, tx := range []*containers.Tx[string, int]{a, b} {
+		n, _ := tx.Index("n")
+		tx.SetIndex("n", n+1)
+	}
+	if err := a.Commit(); err != nil {
+		t.Fatalf("first Commit: %v", err)
+	}
+	if err := b.Commit(); !errors.Is(err, containers.ErrConflict) {
+		t.Fatalf("conflicting Commit: got %v; want ErrConflict", err)
+	}
+	if n, _ := tr.Index("n"); n != 1 {
+		t.Errorf("after conflict, n = %d; want 1", n)
+	}
+
+	// A transaction that does not touch the key does not conflict.
+	c := tr.Begin()
+	c.SetIndex("other", 1)
+	tr.SetIndex("n", 5)
+	if err := c.Commit(); err != nil {
+		t.Errorf("non-conflicting Commit: %v", err)
+	}
+
+	// A single-key write conflicts with a transaction that read the key.
+	d := tr.Begin()
+	d.Index("n")
+	d.SetIndex("other", 2)
+	tr.Delete("n")
+	if err := d.Commit(); !errors.Is(err, containers.ErrConflict) {
+		t.Errorf("Commit after concurrent Delete: got %v; want ErrConflict", err)
+	}
+}
+
+func TestTxRollback(t *testing.T) {
+	tr := containers.NewTransactional[string, int](containers.Map[string, int]{"a": 1})
+	tx := tr.Begin()
+	tx.SetIndex("a", 2)
+	tx.Delete("b")
+	tx.Rollback()
+	tx.Rollback() // No effect.
+
+	if v, _ := tr.Index("a"); v != 1 {
+		t.Errorf("after Rollback, a = %d; want 1", v)
+	}
+	if err := tx.Commit(); !errors.Is(err, containers.ErrTxDone) {
+		t.Errorf("Commit after Rollback: got %v; want ErrTxDone", err)
+	}
+
+	for name, f := range map[string]func(){
+		"Index":    func() { tx.Index("a") },
+		"SetIndex": func() { tx.SetIndex("a", 3) },
+		"Delete":   func() { tx.Delete("a") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s after Rollback did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestTxReadYourWrites(t *testing.T) {
+	base := containers.Map[string, int]{"a": 1, "b": 2}
+	tr := containers.NewTransactional[string, int](base)
+	tx := tr.Begin()
+
+	tx.SetIndex("a", 10)
+	tx.Delete("b")
+	tx.SetIndex("c", 30)
+	if v, ok := tx.Index("a"); !ok || v != 10 {
+		t.Errorf("tx.Index(a) = %v, %v; want 10, true", v, ok)
+	}
+	if v, ok := tx.Index("b"); ok {
+		t.Errorf("tx.Index(b) = %v, true after Delete; want false", v)
+	}
+	if v, ok := tx.Index("c"); !ok || v != 30 {
+		t.Errorf("tx.Index(c) = %v, %v; want 30, true", v, ok)
+	}
+	if v, _ := tr.Index("a"); v != 1 {
+		t.Errorf("before Commit, committed a = %d; want 1", v)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	want := containers.Map[string, int]{"a": 10, "c": 30}
+	if !maps.Equal(base, want) {
+		t.Errorf("after Commit, base = %v; want %v", base, want)
+	}
+}
+
+func TestTxPrunesVersions(t *testing.T) {
+	tr := containers.NewTransactional[string, int](containers.Map[string, int]{})
+	empty := tr.SizeBytes()
+
+	// With a transaction open, writes must be remembered to detect conflicts.
+	tx := tr.Begin()
+	for i := 0; i < 1000; i++ {
+		k := strconv.Itoa(i)
+		tr.SetIndex(k, i)
+		tr.Delete(k)
+	}
+	if tr.SizeBytes() < empty+1000 {
+		t.Fatalf("SizeBytes() = %d with an open transaction; want versions retained", tr.SizeBytes())
+	}
+
+	// Once no transaction can observe them, they are discarded.
+	tx.Rollback()
+	if size := tr.SizeBytes(); size > 2*empty {
+		t.Errorf("SizeBytes() = %d after Rollback; want about %d", size, empty)
+	}
+	for i := 0; i < 1000; i++ {
+		tr.SetIndex(strconv.Itoa(i), i)
+		tr.Delete(strconv.Itoa(i))
+	}
+	if size := tr.SizeBytes(); size > 2*empty {
+		t.Errorf("SizeBytes() = %d after writes with no open transaction; want about %d", size, empty)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// UnboundedChanOptions configures an UnboundedChan.
+type UnboundedChanOptions struct {
+	// If HighWaterMark is positive, OnHighWater is called with the number of
+	// buffered values each time the buffer grows to HighWaterMark values.
+	// It is called again only after the buffer has drained to half the mark
+	// or less. OnHighWater is called from the channel's internal goroutine,
+	// so it must not block or use the channel.
+	HighWaterMark int
+	OnHighWater   func(n int)
+}
+
+// An UnboundedChan is a channel whose buffer grows as needed,
+// so that sends never block on slow receivers.
+//
+// An internal goroutine moves values from the In channel into a buffer, and
+// from the buffer to the Out channel. The goroutine exits once In has been
+// closed and all buffered values have been received, at which point Out is
+// closed.
+type UnboundedChan[T any] struct {
+	in     chan T
+	out    chan T
+	n      atomic.Int64
+	bufCap atomic.Int64
+}
+
+// NewUnboundedChan returns a new UnboundedChan.
+// If opts is nil, default options are used.
+func NewUnboundedChan[T any](opts *UnboundedChanOptions) *UnboundedChan[T] {
+	c := &UnboundedChan[T]{
+		in:  make(chan T),
+		out: make(chan T),
+	}
+	var o UnboundedChanOptions
+	if opts != nil {
+		o = *opts
+	}
+	go c.run(o)
+	return c
+}
+
+func (c *UnboundedChan[T]) run(o UnboundedChanOptions) {
+	defer close(c.out)
+
+	var buf ring[T]
+	armed := true
+	in := c.in
+	for in != nil || buf.len() > 0 {
+		var out chan T
+		var next T
+		if buf.len() > 0 {
+			out = c.out
+			next = buf.front()
+		}
+
+		select {
+		case x, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			buf.pushBack(x)
+			n := buf.len()
+			c.n.Store(int64(n))
+			c.bufCap.Store(int64(len(buf.buf)))
+			if armed && o.HighWaterMark > 0 && n >= o.HighWaterMark {
+				armed = false
+				if o.OnHighWater != nil {
+					o.OnHighWater(n)
+				}
+			}
+		case out <- next:
+			buf.popFront()
+			n := buf.len()
+			c.n.Store(int64(n))
+			c.bufCap.Store(int64(len(buf.buf)))
+			if n <= o.HighWaterMark/2 {
+				armed = true
+			}
+		}
+	}
+}
+
+// In returns the channel on which to send values.
+// Closing it closes the UnboundedChan.
+func (c *UnboundedChan[T]) In() chan<- T { return c.in }
+
+// Out returns the channel from which to receive values.
+func (c *UnboundedChan[T]) Out() <-chan T { return c.out }
+
+// Len returns the number of buffered values.
+func (c *UnboundedChan[T]) Len() int { return int(c.n.Load()) }
+
+// SizeBytes reports the memory used by c's channels and buffer,
+// not counting the memory referenced by buffered values.
+func (c *UnboundedChan[T]) SizeBytes() int64 {
+	return int64(unsafe.Sizeof(*c)) + 2*chanHeaderSize + c.bufCap.Load()*int64(unsafe.Sizeof(*new(T)))
+}
+
+func (c *UnboundedChan[T]) Send(x T) { c.in <- x }
+func (c *UnboundedChan[T]) Close()   { close(c.in) }
+
+func (c *UnboundedChan[T]) Receive() (T, bool) {
+	x, ok := <-c.out
+	return x, ok
+}
+
+func (c *UnboundedChan[T]) RangeElems(f func(T) bool) {
+	for x := range c.out {
+		if !f(x) {
+			break
+		}
+	}
+}
+
+// A ring is a growable circular buffer.
+type ring[T any] struct {
+	buf   []T
+	head  int
+	count int
+}
+
+func (r *ring[T]) len() int { return r.count }
+
+func (r *ring[T]) front() T { return r.buf[r.head] }
+
+func (r *ring[T]) pushBack(x T) {
+	if r.count == len(r.buf) {
+		r.resize(max(16, 2*len(r.buf)))
+	}
+	r.buf[(r.head+r.count)%len(r.buf)] = x
+	r.count++
+}
+
+func (r *ring[T]) popFront() T {
+	x := r.buf[r.head]
+	r.buf[r.head] = *new(T)
+	r.head = (r.head + 1) % len(r.buf)
+	r.count--
+	if len(r.buf) > 16 && r.count <= len(r.buf)/4 {
+		// Release memory after a burst has drained.
+		r.resize(len(r.buf) / 2)
+	}
+	return x
+}
+
+func (r *ring[T]) resize(n int) {
+	buf := make([]T, n)
+	if r.count > 0 {
+		if r.head+r.count <= len(r.buf) {
+			copy(buf, r.buf[r.head:r.head+r.count])
+		} else {
+			m := copy(buf, r.buf[r.head:])
+			copy(buf[m:], r.buf[:r.count-m])
+		}
+	}
+	r.buf = buf
+	r.head = 0
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package containers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+var (
+	_ containers.Sender[int]     = (*containers.UnboundedChan[int])(nil)
+	_ containers.Receiver[int]   = (*containers.UnboundedChan[int])(nil)
+	_ containers.Closer          = (*containers.UnboundedChan[int])(nil)
+	_ containers.Lenner          = (*containers.UnboundedChan[int])(nil)
+	_ containers.ElemRanger[int] = (*containers.UnboundedChan[int])(nil)
+)
+
+func TestUnboundedChan(t *testing.T) {
+	c := containers.NewUnboundedChan[int](nil)
+
+	// Sends do not block, however many values are buffered.
+	const n = 1000
+	for i := 0; i < n; i++ {
+		c.Send(i)
+	}
+	for i := 0; i < n/2; i++ {
+		if x, ok := c.Receive(); !ok || x != i {
+			t.Fatalf("Receive() = %d, %v; want %d, true", x, ok, i)
+		}
+	}
+
+	// Closing the channel does not discard buffered values.
+	c.Close()
+	want := n / 2
+	c.RangeElems(func(x int) bool {
+		if x != want {
+			t.Errorf("RangeElems: got %d; want %d", x, want)
+		}
+		want++
+		return true
+	})
+	if want != n {
+		t.Errorf("RangeElems stopped after %d; want %d", want, n)
+	}
+	if x, ok := c.Receive(); ok {
+		t.Errorf("Receive() after drain = %d, true; want false", x)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() after drain = %d; want 0", c.Len())
+	}
+}
+
+func TestUnboundedChanHighWater(t *testing.T) {
+	const mark = 4
+	high := make(chan int, 10)
+	c := containers.NewUnboundedChan[int](&containers.UnboundedChanOptions{
+		HighWaterMark: mark,
+		OnHighWater:   func(n int) { high <- n },
+	})
+	defer c.Close()
+
+	expectHigh := func() {
+		t.Helper()
+		select {
+		case n := <-high:
+			if n != mark {
+				t.Errorf("OnHighWater(%d); want OnHighWater(%d)", n, mark)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("OnHighWater not called after buffering %d values", mark)
+		}
+	}
+	expectNoHigh := func() {
+		t.Helper()
+		select {
+		case n := <-high:
+			t.Errorf("unexpected OnHighWater(%d)", n)
+		default:
+		}
+	}
+
+	for i := 0; i < mark; i++ {
+		c.Send(i)
+	}
+	expectHigh()
+
+	// Staying above half the mark does not re-arm the callback.
+	c.Receive()
+	c.Send(0)
+	c.Send(0)
+
+	// Draining to exactly half the mark re-arms it.
+	// (Each Receive also waits for any callback from the previous Send.)
+	for i := 0; i < 3; i++ {
+		c.Receive()
+	}
+	expectNoHigh()
+	c.Send(0)
+	c.Send(0)
+	expectHigh()
+	expectNoHigh()
+}
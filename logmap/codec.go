@@ -0,0 +1,70 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logmap
+
+import (
+	"encoding"
+	"encoding/json"
+)
+
+// A Codec converts values of type T to and from bytes.
+type Codec[T any] interface {
+	// Append appends the encoding of x to b and returns the extended slice.
+	Append(b []byte, x T) ([]byte, error)
+
+	// Decode decodes a value from b, which must not be retained.
+	Decode(b []byte) (T, error)
+}
+
+// StringCodec encodes strings as their raw bytes.
+type StringCodec struct{}
+
+func (StringCodec) Append(b []byte, s string) ([]byte, error) { return append(b, s...), nil }
+func (StringCodec) Decode(b []byte) (string, error)           { return string(b), nil }
+
+// BytesCodec encodes byte slices as themselves.
+type BytesCodec struct{}
+
+func (BytesCodec) Append(b []byte, x []byte) ([]byte, error) { return append(b, x...), nil }
+func (BytesCodec) Decode(b []byte) ([]byte, error)           { return append([]byte(nil), b...), nil }
+
+// JSONCodec encodes values of type T using encoding/json.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Append(b []byte, x T) ([]byte, error) {
+	j, err := json.Marshal(x)
+	if err != nil {
+		return b, err
+	}
+	return append(b, j...), nil
+}
+
+func (JSONCodec[T]) Decode(b []byte) (T, error) {
+	var x T
+	err := json.Unmarshal(b, &x)
+	return x, err
+}
+
+// BinaryCodec encodes values whose pointers implement
+// encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+type BinaryCodec[T any, PT interface {
+	*T
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}] struct{}
+
+func (BinaryCodec[T, PT]) Append(b []byte, x T) ([]byte, error) {
+	m, err := PT(&x).MarshalBinary()
+	if err != nil {
+		return b, err
+	}
+	return append(b, m...), nil
+}
+
+func (BinaryCodec[T, PT]) Decode(b []byte) (T, error) {
+	var x T
+	err := PT(&x).UnmarshalBinary(b)
+	return x, err
+}
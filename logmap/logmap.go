@@ -0,0 +1,558 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package logmap implements a persistent key/value map backed by an
+// append-only log file.
+//
+// A Map keeps an in-memory index from each key to the location of its latest
+// value in the log. SetIndex appends a record containing the new value, and
+// Delete appends a tombstone. When a log is reopened, it is replayed to
+// rebuild the index; a torn or corrupt record at the end of the log, such as
+// one left behind by a crash during a write, is truncated away.
+//
+// Superseded records are reclaimed by compaction, which rewrites the live
+// records to a new file and atomically renames it over the log.
+package logmap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// Each record in the log has the form:
+//
+//	crc32c(payload) uint32
+//	len(payload)    uint32
+//	payload:
+//		op     byte
+//		keyLen uvarint
+//		key    [keyLen]byte
+//		value  [...]byte
+const (
+	headerLen = 8
+	maxRecord = 1 << 30
+
+	opSet    = 1
+	opDelete = 2
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTorn indicates an incomplete or corrupt record.
+var errTorn = errors.New("torn record")
+
+var errClosed = errors.New("logmap: use of closed Map")
+
+// defaultCompactGarbage is the default value for Options.CompactGarbage.
+const defaultCompactGarbage = 1 << 20
+
+// Options configures a Map.
+type Options struct {
+	// CompactGarbage is the number of bytes of superseded records at which
+	// the Map starts a compaction in the background, provided that the
+	// garbage also outweighs the live records.
+	//
+	// If zero, a default of 1 MiB is used.
+	// If negative, the Map is compacted only by explicit calls to Compact.
+	CompactGarbage int64
+}
+
+// A Map is a persistent map from K to V stored in a log file.
+// It is safe for concurrent use by multiple goroutines.
+//
+// SetIndex and Delete cannot report I/O errors directly. Instead, the first
+// such error is retained and returned by subsequent calls to Sync and Close.
+type Map[K comparable, V any] struct {
+	path      string
+	keys      Codec[K]
+	vals      Codec[V]
+	threshold int64
+
+	compactMu sync.Mutex // held for the duration of each compaction
+	wg        sync.WaitGroup
+
+	errMu sync.Mutex
+	err   error
+
+	mu     sync.RWMutex
+	f      *os.File
+	index  map[K]entry
+	size   int64 // total bytes in the log
+	live   int64 // bytes of records referenced by index
+	buf    []byte
+	closed bool
+}
+
+var (
+	_ containers.IndexSetter[string, string] = (*Map[string, string])(nil)
+	_ containers.Deleter[string]             = (*Map[string, string])(nil)
+	_ containers.Ranger[string, string]      = (*Map[string, string])(nil)
+)
+
+// An entry locates the latest record for a key.
+type entry struct {
+	off  int64 // offset of the record
+	n    int32 // length of the record, including its header
+	voff int32 // offset of the value within the record
+}
+
+// Open opens the Map stored in the log file at path, creating the file if
+// it does not exist. Keys and values are encoded with the given codecs.
+// If opts is nil, default options are used.
+func Open[K comparable, V any](path string, keys Codec[K], vals Codec[V], opts *Options) (*Map[K, V], error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Map[K, V]{
+		path:      path,
+		keys:      keys,
+		vals:      vals,
+		threshold: defaultCompactGarbage,
+		f:         f,
+		index:     make(map[K]entry),
+	}
+	if opts != nil && opts.CompactGarbage != 0 {
+		m.threshold = opts.CompactGarbage
+	}
+
+	end, err := scan(bufio.NewReader(f), 0, func(op byte, key, rec []byte, e entry) error {
+		return m.replay(op, key, e)
+	})
+	if err == errTorn {
+		// The log ends with a partial write. Discard it, so that new records
+		// are appended after the last complete one.
+		err = f.Truncate(end)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("logmap: replaying %s: %w", path, err)
+	}
+	m.size = end
+	return m, nil
+}
+
+// replay applies a record read from the log to the index.
+// m.mu must be held or m must not yet be shared.
+func (m *Map[K, V]) replay(op byte, key []byte, e entry) error {
+	k, err := m.keys.Decode(key)
+	if err != nil {
+		return fmt.Errorf("decoding key at offset %d: %w", e.off, err)
+	}
+	if old, ok := m.index[k]; ok {
+		m.live -= int64(old.n)
+	}
+	switch op {
+	case opSet:
+		m.index[k] = e
+		m.live += int64(e.n)
+	case opDelete:
+		delete(m.index, k)
+	default:
+		return fmt.Errorf("unknown operation %d at offset %d", op, e.off)
+	}
+	return nil
+}
+
+// scan reads the records from r, which begins at offset off in the log,
+// and calls fn for each one.
+//
+// scan returns the offset just past the last complete record. If r ends with
+// an incomplete or corrupt record, scan returns errTorn.
+func scan(r io.Reader, off int64, fn func(op byte, key, rec []byte, e entry) error) (int64, error) {
+	var buf []byte
+	for {
+		var hdr [headerLen]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF {
+				return off, nil
+			}
+			if err == io.ErrUnexpectedEOF {
+				return off, errTorn
+			}
+			return off, err
+		}
+		n := binary.LittleEndian.Uint32(hdr[4:])
+		if n == 0 || n > maxRecord {
+			return off, errTorn
+		}
+
+		if cap(buf) < headerLen+int(n) {
+			buf = make([]byte, headerLen+int(n))
+		}
+		rec := buf[:headerLen+int(n)]
+		copy(rec, hdr[:])
+		payload := rec[headerLen:]
+		if _, err := io.ReadFull(r, payload); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return off, errTorn
+			}
+			return off, err
+		}
+		if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(hdr[:]) {
+			return off, errTorn
+		}
+
+		op := payload[0]
+		klen, w := binary.Uvarint(payload[1:])
+		if w <= 0 || klen > uint64(len(payload)-1-w) {
+			return off, errTorn
+		}
+		kstart := 1 + w
+		key := payload[kstart : kstart+int(klen)]
+		e := entry{
+			off:  off,
+			n:    int32(len(rec)),
+			voff: int32(headerLen + kstart + int(klen)),
+		}
+		if err := fn(op, key, rec, e); err != nil {
+			return off, err
+		}
+		off += int64(len(rec))
+	}
+}
+
+func (m *Map[K, V]) setErr(err error) {
+	m.errMu.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.errMu.Unlock()
+}
+
+func (m *Map[K, V]) firstErr() error {
+	m.errMu.Lock()
+	defer m.errMu.Unlock()
+	return m.err
+}
+
+// appendRecord encodes a record and appends it to the log.
+// m.mu must be held for writing.
+func (m *Map[K, V]) appendRecord(op byte, k K, v *V) (entry, error) {
+	if m.closed {
+		return entry{}, errClosed
+	}
+
+	b := append(m.buf[:0], make([]byte, headerLen)...)
+	b = append(b, op)
+	kstart := len(b)
+	b, err := m.keys.Append(b, k)
+	if err != nil {
+		return entry{}, fmt.Errorf("logmap: encoding key: %w", err)
+	}
+	key := append([]byte(nil), b[kstart:]...)
+	b = binary.AppendUvarint(b[:kstart], uint64(len(key)))
+	b = append(b, key...)
+	voff := len(b)
+	if v != nil {
+		b, err = m.vals.Append(b, *v)
+		if err != nil {
+			return entry{}, fmt.Errorf("logmap: encoding value: %w", err)
+		}
+	}
+	m.buf = b
+	if len(b)-headerLen > maxRecord {
+		return entry{}, errors.New("logmap: record too large")
+	}
+
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(b)-headerLen))
+	binary.LittleEndian.PutUint32(b[0:], crc32.Checksum(b[headerLen:], crcTable))
+	if _, err := m.f.WriteAt(b, m.size); err != nil {
+		return entry{}, err
+	}
+	e := entry{off: m.size, n: int32(len(b)), voff: int32(voff)}
+	m.size += int64(len(b))
+	return e, nil
+}
+
+func (m *Map[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.index)
+}
+
+// Index returns the value stored for k.
+// If the value cannot be read or decoded, Index reports false
+// and the error is returned by the next call to Sync.
+func (m *Map[K, V]) Index(k K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.index[k]
+	if !ok {
+		return *new(V), false
+	}
+	b := make([]byte, e.n-e.voff)
+	if _, err := m.f.ReadAt(b, e.off+int64(e.voff)); err != nil {
+		m.setErr(fmt.Errorf("logmap: reading value at offset %d: %w", e.off, err))
+		return *new(V), false
+	}
+	v, err := m.vals.Decode(b)
+	if err != nil {
+		m.setErr(fmt.Errorf("logmap: decoding value at offset %d: %w", e.off, err))
+		return *new(V), false
+	}
+	return v, true
+}
+
+func (m *Map[K, V]) SetIndex(k K, v V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, err := m.appendRecord(opSet, k, &v)
+	if err != nil {
+		m.setErr(err)
+		return
+	}
+	if old, ok := m.index[k]; ok {
+		m.live -= int64(old.n)
+	}
+	m.index[k] = e
+	m.live += int64(e.n)
+	m.maybeCompact()
+}
+
+func (m *Map[K, V]) Delete(k K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.index[k]
+	if !ok {
+		return
+	}
+	if _, err := m.appendRecord(opDelete, k, nil); err != nil {
+		m.setErr(err)
+		return
+	}
+	delete(m.index, k)
+	m.live -= int64(old.n)
+	m.maybeCompact()
+}
+
+// RangeKeys calls f for each key in m, in arbitrary order.
+//
+// As with a built-in map, f may modify m: keys deleted before they are
+// reached are not produced, and keys added during iteration may or may not be.
+func (m *Map[K, V]) RangeKeys(f func(K) bool) {
+	for _, k := range m.keySnapshot() {
+		m.mu.RLock()
+		_, ok := m.index[k]
+		m.mu.RUnlock()
+		if ok && !f(k) {
+			break
+		}
+	}
+}
+
+// RangeElems calls f for each value in m, in arbitrary order.
+// It follows the same rules for concurrent modification as RangeKeys.
+func (m *Map[K, V]) RangeElems(f func(V) bool) {
+	for _, k := range m.keySnapshot() {
+		if v, ok := m.Index(k); ok && !f(v) {
+			break
+		}
+	}
+}
+
+// Range calls f for each key–value pair in m, in arbitrary order.
+// It follows the same rules for concurrent modification as RangeKeys.
+func (m *Map[K, V]) Range(f func(K, V) bool) {
+	for _, k := range m.keySnapshot() {
+		if v, ok := m.Index(k); ok && !f(k, v) {
+			break
+		}
+	}
+}
+
+func (m *Map[K, V]) keySnapshot() []K {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]K, 0, len(m.index))
+	for k := range m.index {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Sync commits the log to stable storage.
+// It returns the first error encountered by any earlier operation on m,
+// if there was one.
+func (m *Map[K, V]) Sync() error {
+	if err := m.firstErr(); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return errClosed
+	}
+	return m.f.Sync()
+}
+
+// Close waits for any compaction in progress, syncs the log, and closes it.
+func (m *Map[K, V]) Close() error {
+	m.compactMu.Lock()
+	defer m.compactMu.Unlock()
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return errors.New("logmap: Map already closed")
+	}
+	m.closed = true
+	m.mu.Unlock()
+	m.wg.Wait()
+
+	serr := m.f.Sync()
+	cerr := m.f.Close()
+	if err := m.firstErr(); err != nil {
+		return err
+	}
+	if serr != nil {
+		return serr
+	}
+	return cerr
+}
+
+// maybeCompact starts a background compaction if enough of the log is garbage.
+// m.mu must be held for writing.
+func (m *Map[K, V]) maybeCompact() {
+	garbage := m.size - m.live
+	if m.threshold < 0 || garbage < m.threshold || garbage <= m.live {
+		return
+	}
+	if !m.compactMu.TryLock() {
+		return // Already compacting.
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.compactMu.Unlock()
+		if err := m.compact(); err != nil {
+			m.setErr(err)
+		}
+	}()
+}
+
+// Compact rewrites the log to contain only the latest record for each key.
+//
+// Reads and writes may proceed concurrently with most of the work of
+// compaction; they are blocked only while the records appended during the
+// compaction are copied and the new log is swapped in.
+func (m *Map[K, V]) Compact() error {
+	m.compactMu.Lock()
+	defer m.compactMu.Unlock()
+	return m.compact()
+}
+
+// compact implements Compact. m.compactMu must be held.
+func (m *Map[K, V]) compact() (err error) {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return errClosed
+	}
+	old := m.f
+	end := m.size
+	snapshot := make(map[K]entry, len(m.index))
+	for k, e := range m.index {
+		snapshot[k] = e
+	}
+	m.mu.RUnlock()
+
+	tmpPath := m.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return fmt.Errorf("logmap: compacting: %w", err)
+	}
+	renamed := false
+	defer func() {
+		if err != nil {
+			if !renamed {
+				tmp.Close()
+				os.Remove(tmpPath)
+			}
+			err = fmt.Errorf("logmap: compacting: %w", err)
+		}
+	}()
+
+	// Copy the live records as of the snapshot. Records before end are never
+	// modified, so this does not need to hold m.mu.
+	w := bufio.NewWriter(tmp)
+	index := make(map[K]entry, len(snapshot))
+	var size int64
+	var buf []byte
+	for k, e := range snapshot {
+		if cap(buf) < int(e.n) {
+			buf = make([]byte, e.n)
+		}
+		rec := buf[:e.n]
+		if _, err := old.ReadAt(rec, e.off); err != nil {
+			return err
+		}
+		if _, err := w.Write(rec); err != nil {
+			return err
+		}
+		index[k] = entry{off: size, n: e.n, voff: e.voff}
+		size += int64(e.n)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Copy over and replay the records appended since the snapshot.
+	newMap := &Map[K, V]{keys: m.keys, index: index, live: size}
+	tail := io.NewSectionReader(old, end, m.size-end)
+	size, err = scan(bufio.NewReader(tail), size, func(op byte, key, rec []byte, e entry) error {
+		if _, err := w.Write(rec); err != nil {
+			return err
+		}
+		return newMap.replay(op, key, e)
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		return err
+	}
+	renamed = true
+
+	old.Close()
+	m.f = tmp
+	m.index = newMap.index
+	m.size = size
+	m.live = newMap.live
+
+	// The rename is not durable until the directory containing it is synced.
+	return syncDir(filepath.Dir(m.path))
+}
+
+// syncDir commits the entries of the directory at path to stable storage.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	serr := d.Sync()
+	cerr := d.Close()
+	if serr != nil {
+		return serr
+	}
+	return cerr
+}
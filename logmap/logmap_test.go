@@ -0,0 +1,144 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logmap_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/bcmills/go2go/logmap"
+)
+
+func open(t *testing.T, path string, opts *logmap.Options) *logmap.Map[string, int] {
+	t.Helper()
+	m, err := logmap.Open[string, int](path, logmap.StringCodec{}, logmap.JSONCodec[int]{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+
+	m := open(t, path, nil)
+	m.SetIndex("a", 1)
+	m.SetIndex("b", 2)
+	m.SetIndex("a", 3)
+	m.Delete("b")
+	m.SetIndex("c", 4)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of appending a record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0xde, 0xad, 0xbe, 0xef, 20, 0})
+	f.Close()
+
+	m = open(t, path, nil)
+	defer m.Close()
+	want := map[string]int{"a": 3, "c": 4}
+	got := map[string]int{}
+	m.Range(func(k string, v int) bool {
+		got[k] = v
+		return true
+	})
+	if len(got) != len(want) || got["a"] != want["a"] || got["c"] != want["c"] {
+		t.Errorf("after reopening, contents = %v; want %v", got, want)
+	}
+
+	// The torn record must have been discarded, so that new writes survive.
+	m.SetIndex("d", 5)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	m = open(t, path, nil)
+	defer m.Close()
+	if v, ok := m.Index("d"); !ok || v != 5 {
+		t.Errorf(`Index("d") = %v, %v; want 5, true`, v, ok)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	m := open(t, path, &logmap.Options{CompactGarbage: -1})
+
+	for i := 0; i < 1000; i++ {
+		m.SetIndex(strconv.Itoa(i%10), i)
+	}
+	m.Delete("9")
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size()/10 {
+		t.Errorf("log size after Compact = %d; want less than a tenth of %d", after.Size(), before.Size())
+	}
+
+	m.SetIndex("0", -1)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m = open(t, path, nil)
+	defer m.Close()
+	if n := m.Len(); n != 9 {
+		t.Errorf("Len() = %d; want 9", n)
+	}
+	if v, ok := m.Index("0"); !ok || v != -1 {
+		t.Errorf(`Index("0") = %v, %v; want -1, true`, v, ok)
+	}
+	if v, ok := m.Index("8"); !ok || v != 998 {
+		t.Errorf(`Index("8") = %v, %v; want 998, true`, v, ok)
+	}
+	if _, ok := m.Index("9"); ok {
+		t.Errorf(`Index("9") reported present after Delete`)
+	}
+}
+
+func TestBackgroundCompact(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path string, opts *logmap.Options) int64 {
+		m := open(t, path, opts)
+		for i := 0; i < 10000; i++ {
+			m.SetIndex(strconv.Itoa(i%50), i)
+		}
+		if err := m.Close(); err != nil {
+			t.Fatal(err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Size()
+	}
+	full := write(filepath.Join(dir, "full"), &logmap.Options{CompactGarbage: -1})
+	path := filepath.Join(dir, "log")
+	if size := write(path, &logmap.Options{CompactGarbage: 4096}); size >= full {
+		t.Errorf("log size = %d; want background compaction to reduce it below %d", size, full)
+	}
+
+	m := open(t, path, nil)
+	defer m.Close()
+	for i := 9950; i < 10000; i++ {
+		k := strconv.Itoa(i % 50)
+		if v, ok := m.Index(k); !ok || v != i {
+			t.Errorf("Index(%q) = %v, %v; want %v, true", k, v, ok, i)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pubsub implements an in-process publish/subscribe broker.
+//
+// Topics are sequences of non-empty tokens separated by dots, such as
+// "orders.eu.created". A subscription pattern may use "*" in place of any one
+// token, and may end with ">" to match one or more remaining tokens:
+// "orders.*.created" matches "orders.eu.created", and "orders.>" matches
+// every topic beginning with "orders.".
+//
+// Each Subscription buffers the values published to it, and implements
+// containers.Receiver, so existing consumers can read from it unchanged.
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// A Policy determines what Publish does when a subscriber's buffer is full.
+type Policy int
+
+const (
+	// Block makes Publish wait until the subscriber has room.
+	Block Policy = iota
+
+	// DropNewest discards the value being published.
+	DropNewest
+
+	// DropOldest discards the oldest buffered value to make room.
+	DropOldest
+
+	// Unbounded grows the buffer without limit.
+	Unbounded
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Block:
+		return "Block"
+	case DropNewest:
+		return "DropNewest"
+	case DropOldest:
+		return "DropOldest"
+	case Unbounded:
+		return "Unbounded"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// SubscribeOptions configures a Subscription.
+type SubscribeOptions struct {
+	// Buffer is the number of values the subscription holds before Policy
+	// applies. If it is not positive, it defaults to 1.
+	Buffer int
+	Policy Policy
+}
+
+// A Broker delivers values published to a topic to every Subscription whose
+// pattern matches that topic. It is safe for concurrent use by multiple
+// goroutines.
+type Broker[T any] struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]bool
+	closed bool
+}
+
+// New returns a new Broker with no subscriptions.
+func New[T any]() *Broker[T] {
+	return &Broker[T]{subs: make(map[*Subscription[T]]bool)}
+}
+
+// Subscribe returns a new Subscription to the topics matching pattern.
+// If opts is nil, default options are used.
+//
+// Subscribe panics if pattern is malformed.
+// If b is closed, the returned Subscription is already closed.
+func (b *Broker[T]) Subscribe(pattern string, opts *SubscribeOptions) *Subscription[T] {
+	tokens, ok := parse(pattern, true)
+	if !ok {
+		panic(fmt.Sprintf("pubsub: invalid pattern %q", pattern))
+	}
+	s := &Subscription[T]{
+		broker:  b,
+		pattern: pattern,
+		tokens:  tokens,
+		size:    1,
+	}
+	if opts != nil {
+		s.policy = opts.Policy
+		if opts.Buffer > 0 {
+			s.size = opts.Buffer
+		}
+	}
+	s.cond.L = &s.mu
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		s.closed = true
+	} else {
+		b.subs[s] = true
+	}
+	return s
+}
+
+// Unsubscribe removes s from b, as if by s.Unsubscribe.
+func (b *Broker[T]) Unsubscribe(s *Subscription[T]) {
+	b.mu.Lock()
+	delete(b.subs, s)
+	b.mu.Unlock()
+	s.close()
+}
+
+// Publish delivers x to every subscription matching topic, applying each
+// subscription's Policy if its buffer is full. It returns the number of
+// subscriptions to which x was delivered.
+//
+// Publish panics if topic is malformed or contains wildcards.
+func (b *Broker[T]) Publish(topic string, x T) int {
+	tokens, ok := parse(topic, false)
+	if !ok {
+		panic(fmt.Sprintf("pubsub: invalid topic %q", topic))
+	}
+
+	b.mu.RLock()
+	var matched []*Subscription[T]
+	for s := range b.subs {
+		if match(s.tokens, tokens) {
+			matched = append(matched, s)
+		}
+	}
+	b.mu.RUnlock()
+
+	// Deliver without holding b.mu, so that a subscriber blocking this
+	// Publish can still be unsubscribed.
+	n := 0
+	for _, s := range matched {
+		if s.deliver(x) {
+			n++
+		}
+	}
+	return n
+}
+
+// Publisher returns a Sender that publishes each value it is sent to topic.
+func (b *Broker[T]) Publisher(topic string) containers.Sender[T] {
+	if _, ok := parse(topic, false); !ok {
+		panic(fmt.Sprintf("pubsub: invalid topic %q", topic))
+	}
+	return publisher[T]{b, topic}
+}
+
+type publisher[T any] struct {
+	b     *Broker[T]
+	topic string
+}
+
+func (p publisher[T]) Send(x T) { p.b.Publish(p.topic, x) }
+
+// Close unsubscribes all subscriptions. Subsequent calls to Publish
+// deliver nothing.
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = make(map[*Subscription[T]]bool)
+	b.closed = true
+	b.mu.Unlock()
+
+	for s := range subs {
+		s.close()
+	}
+}
+
+// A Subscription receives the values published to topics matching its
+// pattern, in the order in which they were published.
+//
+// Once a Subscription has been unsubscribed, Receive returns its remaining
+// buffered values and then reports false.
+type Subscription[T any] struct {
+	broker  *Broker[T]
+	pattern string
+	tokens  []string
+	policy  Policy
+	size    int
+
+	mu      sync.Mutex
+	cond    sync.Cond // signaled when buf or closed changes
+	buf     []T
+	head    int
+	dropped int64
+	closed  bool
+}
+
+var (
+	_ containers.Receiver[int] = (*Subscription[int])(nil)
+	_ containers.Lenner        = (*Subscription[int])(nil)
+	_ containers.Closer        = (*Subscription[int])(nil)
+)
+
+// Pattern returns the pattern with which s was subscribed.
+func (s *Subscription[T]) Pattern() string { return s.pattern }
+
+// Len returns the number of buffered values.
+func (s *Subscription[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buf) - s.head
+}
+
+// Dropped returns the number of values discarded by the DropNewest or
+// DropOldest policy.
+func (s *Subscription[T]) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Subscription[T]) deliver(x T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.policy != Unbounded {
+		for !s.closed && len(s.buf)-s.head >= s.size {
+			switch s.policy {
+			case DropNewest:
+				s.dropped++
+				return false
+			case DropOldest:
+				s.pop()
+				s.dropped++
+			default:
+				s.cond.Wait()
+			}
+		}
+	}
+	if s.closed {
+		return false
+	}
+	s.buf = append(s.buf, x)
+	s.cond.Broadcast()
+	return true
+}
+
+func (s *Subscription[T]) pop() T {
+	x := s.buf[s.head]
+	s.buf[s.head] = *new(T)
+	s.head++
+	if s.head == len(s.buf) {
+		s.buf = s.buf[:0]
+		s.head = 0
+	} else if s.head > 32 && s.head >= len(s.buf)/2 {
+		n := copy(s.buf, s.buf[s.head:])
+		clear(s.buf[n:])
+		s.buf = s.buf[:n]
+		s.head = 0
+	}
+	return x
+}
+
+// Receive returns the next value published to s, blocking until one is
+// available. It returns false once s has been unsubscribed and drained.
+func (s *Subscription[T]) Receive() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.head == len(s.buf) {
+		if s.closed {
+			return *new(T), false
+		}
+		s.cond.Wait()
+	}
+	x := s.pop()
+	s.cond.Broadcast()
+	return x, true
+}
+
+func (s *Subscription[T]) RangeElems(f func(T) bool) {
+	for {
+		x, ok := s.Receive()
+		if !ok || !f(x) {
+			return
+		}
+	}
+}
+
+// Unsubscribe stops delivery of new values to s.
+func (s *Subscription[T]) Unsubscribe() { s.broker.Unsubscribe(s) }
+
+// Close is a synonym for Unsubscribe.
+func (s *Subscription[T]) Close() { s.Unsubscribe() }
+
+func (s *Subscription[T]) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// parse splits a topic or pattern into tokens, reporting whether it is
+// well-formed.
+func parse(s string, wildcards bool) ([]string, bool) {
+	tokens := strings.Split(s, ".")
+	for i, t := range tokens {
+		switch {
+		case t == "":
+			return nil, false
+		case t == "*", t == ">" && i == len(tokens)-1:
+			if !wildcards {
+				return nil, false
+			}
+		case strings.ContainsAny(t, "*>"):
+			return nil, false
+		}
+	}
+	return tokens, true
+}
+
+func match(pattern, topic []string) bool {
+	for i, p := range pattern {
+		if p == ">" {
+			return len(topic) > i
+		}
+		if i >= len(topic) || (p != "*" && p != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
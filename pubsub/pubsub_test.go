@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pubsub_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bcmills/go2go/containers"
+	"github.com/bcmills/go2go/pubsub"
+)
+
+func TestWildcards(t *testing.T) {
+	b := pubsub.New[string]()
+	defer b.Close()
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"orders.eu.created", []string{"orders.eu.created"}},
+		{"orders.*.created", []string{"orders.eu.created", "orders.us.created"}},
+		{"orders.>", []string{"orders.eu.created", "orders.us.created", "orders.eu.paid.late"}},
+		{"*", []string{"orders"}},
+		{">", []string{"orders", "orders.eu.created", "orders.us.created", "orders.eu.paid.late"}},
+	}
+	subs := make([]*pubsub.Subscription[string], len(tests))
+	for i, tt := range tests {
+		subs[i] = b.Subscribe(tt.pattern, &pubsub.SubscribeOptions{Policy: pubsub.Unbounded})
+	}
+
+	for _, topic := range []string{"orders", "orders.eu.created", "orders.us.created", "orders.eu.paid.late"} {
+		b.Publish(topic, topic)
+	}
+	b.Close()
+
+	for i, tt := range tests {
+		var got []string
+		subs[i].RangeElems(func(s string) bool {
+			got = append(got, s)
+			return true
+		})
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Subscribe(%q) received %q; want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestDropPolicies(t *testing.T) {
+	b := pubsub.New[int]()
+	newest := b.Subscribe("t", &pubsub.SubscribeOptions{Buffer: 2, Policy: pubsub.DropNewest})
+	oldest := b.Subscribe("t", &pubsub.SubscribeOptions{Buffer: 2, Policy: pubsub.DropOldest})
+
+	var p containers.Sender[int] = b.Publisher("t")
+	for i := 0; i < 5; i++ {
+		p.Send(i)
+	}
+	b.Close()
+
+	for _, tt := range []struct {
+		sub  *pubsub.Subscription[int]
+		want []int
+	}{
+		{newest, []int{0, 1}},
+		{oldest, []int{3, 4}},
+	} {
+		if n := tt.sub.Dropped(); n != 3 {
+			t.Errorf("%v: Dropped() = %d; want 3", tt.want, n)
+		}
+		for _, want := range tt.want {
+			if x, ok := tt.sub.Receive(); !ok || x != want {
+				t.Errorf("Receive() = %d, %v; want %d, true", x, ok, want)
+			}
+		}
+		if x, ok := tt.sub.Receive(); ok {
+			t.Errorf("Receive() = %d, true after Close; want false", x)
+		}
+	}
+}
+
+func TestBlockUntilUnsubscribe(t *testing.T) {
+	b := pubsub.New[int]()
+	defer b.Close()
+	s := b.Subscribe("t", nil)
+
+	if n := b.Publish("t", 1); n != 1 {
+		t.Fatalf("Publish = %d; want 1", n)
+	}
+
+	done := make(chan int)
+	go func() { done <- b.Publish("t", 2) }()
+
+	select {
+	case <-done:
+		t.Fatalf("Publish to a full subscription did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	var r containers.Receiver[int] = s
+	if x, ok := r.Receive(); !ok || x != 1 {
+		t.Fatalf("Receive() = %d, %v; want 1, true", x, ok)
+	}
+	if n := <-done; n != 1 {
+		t.Fatalf("Publish = %d; want 1", n)
+	}
+
+	go func() { done <- b.Publish("t", 3) }()
+	time.Sleep(10 * time.Millisecond)
+	s.Unsubscribe()
+	if n := <-done; n != 0 {
+		t.Errorf("Publish after Unsubscribe = %d; want 0", n)
+	}
+	if x, ok := s.Receive(); !ok || x != 2 {
+		t.Errorf("Receive() = %d, %v; want 2, true", x, ok)
+	}
+	if _, ok := s.Receive(); ok {
+		t.Errorf("Receive() after Unsubscribe succeeded; want false")
+	}
+}
@@ -93,3 +93,13 @@ func AsPointer[E, T any](src []E) *T {
 func SetPointer[T, E any](dst **T, src []E) {
 	*dst = AsPointer[E, T](src)
 }
+
+// AsString returns a string that refers to the same memory as b,
+// without copying.
+//
+// The caller must ensure that b is not modified for as long as the string
+// (or any string derived from it) is in use. Typically, AsString is used to
+// look up a map key or compare against a string without allocating.
+func AsString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
@@ -159,3 +159,19 @@ func TestConvertAtTypeInference(t *testing.T) {
 	// 	cannot use &s (value of type *sliceOfBig) as *[]big value in argument
 	unsafeslice.ConvertAt((*[]big)(&s), b)
 }
+
+func TestAsString(t *testing.T) {
+	b := []byte("Hello, world!")
+	s := unsafeslice.AsString(b)
+	if s != "Hello, world!" {
+		t.Errorf("AsString(%q) = %q", b, s)
+	}
+	if unsafeslice.AsString(nil) != "" {
+		t.Errorf("AsString(nil) is not empty")
+	}
+
+	m := map[string]int{"Hello, world!": 1}
+	if n := testing.AllocsPerRun(100, func() { _ = m[unsafeslice.AsString(b)] }); n != 0 {
+		t.Errorf("map lookup with AsString allocated %v times; want 0", n)
+	}
+}
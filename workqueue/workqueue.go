@@ -0,0 +1,379 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package workqueue implements a queue of keys for controller-style
+// reconcile loops.
+//
+// A Queue deduplicates keys: a key that is already waiting to be processed is
+// not queued a second time. It also guarantees that each key is processed by
+// at most one worker at a time: a key that is sent while it is being processed
+// is queued again only once the worker calls Done.
+//
+// Keys can be delayed with AddAfter, or retried with per-key exponential
+// backoff with AddRateLimited.
+package workqueue
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/bcmills/go2go/containers"
+)
+
+// Options configures a Queue.
+type Options struct {
+	// Clock is the source of time for delayed keys.
+	// If nil, containers.SystemClock is used.
+	Clock containers.Clock
+
+	// BaseDelay is the delay before the first rate-limited retry of a key.
+	// Each subsequent retry doubles the delay, up to MaxDelay.
+	// If zero, they default to 5ms and 1000s respectively.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// A Queue is a deduplicating, prioritized work queue of keys.
+// It is safe for concurrent use by multiple goroutines.
+type Queue[K comparable] struct {
+	clock     containers.Clock
+	baseDelay time.Duration
+	maxDelay  time.Duration
+
+	mu         sync.Mutex
+	cond       sync.Cond
+	ready      readyHeap[K]
+	queued     map[K]*readyItem[K]
+	processing map[K]bool
+	requeue    map[K]int // keys sent during processing, with their priority
+	waiting    waitHeap[K]
+	delayed    map[K]*waitItem[K]
+	failures   map[K]int
+	seq        uint64
+	closed     bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+var (
+	_ containers.Sender[string]   = (*Queue[string])(nil)
+	_ containers.Receiver[string] = (*Queue[string])(nil)
+	_ containers.Closer           = (*Queue[string])(nil)
+	_ containers.Lenner           = (*Queue[string])(nil)
+)
+
+// New returns a new, empty Queue.
+// If opts is nil, default options are used.
+func New[K comparable](opts *Options) *Queue[K] {
+	q := &Queue[K]{
+		clock:      containers.SystemClock,
+		baseDelay:  5 * time.Millisecond,
+		maxDelay:   1000 * time.Second,
+		queued:     make(map[K]*readyItem[K]),
+		processing: make(map[K]bool),
+		requeue:    make(map[K]int),
+		delayed:    make(map[K]*waitItem[K]),
+		failures:   make(map[K]int),
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	q.cond.L = &q.mu
+	if opts != nil {
+		if opts.Clock != nil {
+			q.clock = opts.Clock
+		}
+		if opts.BaseDelay > 0 {
+			q.baseDelay = opts.BaseDelay
+		}
+		if opts.MaxDelay > 0 {
+			q.maxDelay = opts.MaxDelay
+		}
+	}
+	go q.waitLoop()
+	return q
+}
+
+// Len returns the number of keys that are ready to be received.
+func (q *Queue[K]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ready)
+}
+
+// Send adds k to the queue with priority 0.
+func (q *Queue[K]) Send(k K) {
+	q.SendPriority(k, 0)
+}
+
+// SendPriority adds k to the queue with the given priority.
+// Keys with higher priorities are received first;
+// keys with equal priorities are received in the order they were added.
+//
+// If k is already queued, its priority is raised to the given one if that is
+// higher. If k is being processed, it is queued again once Done is called.
+func (q *Queue[K]) SendPriority(k K, priority int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.add(k, priority)
+}
+
+// add adds k to the ready queue. q.mu must be held.
+func (q *Queue[K]) add(k K, priority int) {
+	if q.closed {
+		return
+	}
+	if q.processing[k] {
+		if p, ok := q.requeue[k]; !ok || priority > p {
+			q.requeue[k] = priority
+		}
+		return
+	}
+	if it, ok := q.queued[k]; ok {
+		if priority > it.priority {
+			it.priority = priority
+			heap.Fix(&q.ready, it.index)
+		}
+		return
+	}
+	q.seq++
+	it := &readyItem[K]{key: k, priority: priority, seq: q.seq}
+	heap.Push(&q.ready, it)
+	q.queued[k] = it
+	q.cond.Signal()
+}
+
+// Receive blocks until a key is ready, marks it as being processed,
+// and returns it. The caller must call Done with the key when it has finished
+// processing it.
+//
+// Once the queue is closed and no keys remain ready,
+// Receive returns the zero K and false.
+func (q *Queue[K]) Receive() (K, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.ready) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.ready) == 0 {
+		return *new(K), false
+	}
+	it := heap.Pop(&q.ready).(*readyItem[K])
+	delete(q.queued, it.key)
+	q.processing[it.key] = true
+	return it.key, true
+}
+
+// Done marks k as no longer being processed.
+// If k was sent again during processing, it is queued again.
+func (q *Queue[K]) Done(k K) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, k)
+	if p, ok := q.requeue[k]; ok {
+		delete(q.requeue, k)
+		q.add(k, p)
+	}
+}
+
+// AddAfter adds k to the queue once the duration d has elapsed.
+// If k is already waiting, it is added at the earlier of the two times.
+func (q *Queue[K]) AddAfter(k K, d time.Duration) {
+	if d <= 0 {
+		q.Send(k)
+		return
+	}
+	when := q.clock.Now().Add(d)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	if w, ok := q.delayed[k]; ok {
+		if !when.Before(w.when) {
+			return
+		}
+		w.when = when
+		heap.Fix(&q.waiting, w.index)
+	} else {
+		w := &waitItem[K]{key: k, when: when}
+		heap.Push(&q.waiting, w)
+		q.delayed[k] = w
+	}
+	q.poke()
+}
+
+// AddRateLimited adds k to the queue after a delay that grows exponentially
+// with the number of times k has been rate-limited since it was last
+// forgotten.
+func (q *Queue[K]) AddRateLimited(k K) {
+	q.mu.Lock()
+	n := q.failures[k]
+	q.failures[k] = n + 1
+	q.mu.Unlock()
+
+	d := q.baseDelay
+	for i := 0; i < n && d < q.maxDelay; i++ {
+		d *= 2
+	}
+	if d > q.maxDelay {
+		d = q.maxDelay
+	}
+	q.AddAfter(k, d)
+}
+
+// Forget resets the rate-limiting backoff for k.
+// Callers should call Forget once k has been processed successfully.
+func (q *Queue[K]) Forget(k K) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.failures, k)
+}
+
+// NumRequeues returns the number of times k has been rate-limited
+// since it was last forgotten.
+func (q *Queue[K]) NumRequeues(k K) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.failures[k]
+}
+
+// Close shuts down the queue. Keys that are already ready can still be
+// received, but new and delayed keys are discarded.
+func (q *Queue[K]) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	close(q.stop)
+	<-q.done
+}
+
+// waitLoop moves delayed keys to the ready queue as they come due.
+func (q *Queue[K]) waitLoop() {
+	defer close(q.done)
+
+	// A single timer, rescheduled for the earliest waiting key,
+	// wakes the loop when that key comes due.
+	var timer containers.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		q.mu.Lock()
+		now := q.clock.Now()
+		for len(q.waiting) > 0 && !q.waiting[0].when.After(now) {
+			w := heap.Pop(&q.waiting).(*waitItem[K])
+			delete(q.delayed, w.key)
+			q.add(w.key, 0)
+		}
+		switch {
+		case len(q.waiting) > 0:
+			d := q.waiting[0].when.Sub(now)
+			if timer == nil {
+				timer = q.clock.AfterFunc(d, q.poke)
+			} else {
+				timer.Reset(d)
+			}
+		case timer != nil:
+			timer.Stop()
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		}
+	}
+}
+
+// poke wakes waitLoop, if it is not already due to wake.
+func (q *Queue[K]) poke() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+type readyItem[K any] struct {
+	key      K
+	priority int
+	seq      uint64
+	index    int
+}
+
+type readyHeap[K any] []*readyItem[K]
+
+func (h readyHeap[K]) Len() int { return len(h) }
+
+func (h readyHeap[K]) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h readyHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *readyHeap[K]) Push(x any) {
+	it := x.(*readyItem[K])
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *readyHeap[K]) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return it
+}
+
+type waitItem[K any] struct {
+	key   K
+	when  time.Time
+	index int
+}
+
+type waitHeap[K any] []*waitItem[K]
+
+func (h waitHeap[K]) Len() int           { return len(h) }
+func (h waitHeap[K]) Less(i, j int) bool { return h[i].when.Before(h[j].when) }
+
+func (h waitHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waitHeap[K]) Push(x any) {
+	w := x.(*waitItem[K])
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waitHeap[K]) Pop() any {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package workqueue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bcmills/go2go/containers"
+	"github.com/bcmills/go2go/workqueue"
+)
+
+func receive(t *testing.T, q *workqueue.Queue[string], want string) {
+	t.Helper()
+	k, ok := q.Receive()
+	if !ok || k != want {
+		t.Fatalf("Receive() = %q, %v; want %q, true", k, ok, want)
+	}
+}
+
+func TestDedupAndPriority(t *testing.T) {
+	q := workqueue.New[string](nil)
+	defer q.Close()
+
+	q.Send("a")
+	q.Send("b")
+	q.Send("a")
+	q.SendPriority("c", 1)
+	q.SendPriority("b", 2)
+	if n := q.Len(); n != 3 {
+		t.Fatalf("Len() = %d; want 3", n)
+	}
+	receive(t, q, "b")
+	receive(t, q, "c")
+	receive(t, q, "a")
+}
+
+func TestSingleInFlight(t *testing.T) {
+	q := workqueue.New[string](nil)
+	defer q.Close()
+
+	q.Send("a")
+	receive(t, q, "a")
+
+	// Sending a key that is being processed must not make it available
+	// to another worker until Done.
+	q.Send("a")
+	q.Send("a")
+	if n := q.Len(); n != 0 {
+		t.Fatalf("Len() = %d while key is in flight; want 0", n)
+	}
+	q.Done("a")
+	if n := q.Len(); n != 1 {
+		t.Fatalf("Len() = %d after Done; want 1", n)
+	}
+	receive(t, q, "a")
+	q.Done("a")
+	if n := q.Len(); n != 0 {
+		t.Fatalf("Len() = %d; want 0", n)
+	}
+}
+
+func TestAddAfterAndRateLimited(t *testing.T) {
+	clock := containers.NewManualClock(time.Unix(0, 0))
+	q := workqueue.New[string](&workqueue.Options{
+		Clock:     clock,
+		BaseDelay: time.Second,
+		MaxDelay:  4 * time.Second,
+	})
+	defer q.Close()
+
+	q.AddAfter("a", 10*time.Second)
+	q.AddAfter("a", 5*time.Second)
+
+	clock.Advance(4 * time.Second)
+	if n := q.Len(); n != 0 {
+		t.Fatalf("Len() = %d before delay elapsed; want 0", n)
+	}
+	clock.Advance(time.Second)
+	receive(t, q, "a") // Blocks until the delayed key is moved to the ready queue.
+	q.Done("a")
+
+	for i, want := range []time.Duration{1, 2, 4, 4} {
+		q.AddRateLimited("b")
+		clock.Advance(want*time.Second - 1)
+		if n := q.Len(); n != 0 {
+			t.Fatalf("retry %d: Len() = %d before backoff elapsed; want 0", i, n)
+		}
+		clock.Advance(1)
+		receive(t, q, "b")
+		q.Done("b")
+	}
+	if n := q.NumRequeues("b"); n != 4 {
+		t.Errorf("NumRequeues = %d; want 4", n)
+	}
+	q.Forget("b")
+	if n := q.NumRequeues("b"); n != 0 {
+		t.Errorf("NumRequeues after Forget = %d; want 0", n)
+	}
+}
+
+func TestSingleTimer(t *testing.T) {
+	clock := containers.NewManualClock(time.Unix(0, 0))
+	q := workqueue.New[int](&workqueue.Options{Clock: clock})
+
+	// Each AddAfter reschedules the queue's timer; none of them should leave
+	// a stale waiter behind on the clock.
+	for i := 0; i < 10; i++ {
+		q.AddAfter(i, time.Duration(10-i)*time.Second)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for clock.Waiters() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Waiters() = %d; want 1", clock.Waiters())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(10 * time.Second)
+	for i := 9; i >= 0; i-- {
+		if k, ok := q.Receive(); !ok || k != i {
+			t.Fatalf("Receive() = %v, %v; want %v, true", k, ok, i)
+		}
+	}
+
+	q.Close()
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Waiters() = %d after Close; want 0", n)
+	}
+}
+
+func TestClose(t *testing.T) {
+	q := workqueue.New[int](nil)
+	q.Send(1)
+	q.Close()
+	if k, ok := q.Receive(); !ok || k != 1 {
+		t.Fatalf("Receive() = %v, %v; want 1, true", k, ok)
+	}
+	if k, ok := q.Receive(); ok {
+		t.Fatalf("Receive() = %v, true after Close; want false", k)
+	}
+}